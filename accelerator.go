@@ -0,0 +1,95 @@
+package syso
+
+import (
+	"bytes"
+
+	"github.com/hallazzang/syso/pkg/accel"
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/hallazzang/syso/pkg/rsrc"
+	"github.com/pkg/errors"
+)
+
+// AcceleratorTableResource represents a keyboard accelerator table
+// resource, which can be loaded with LoadAccelerators.
+type AcceleratorTableResource struct {
+	ID       int
+	Name     string
+	Language *string
+	Entries  []*Accelerator
+}
+
+// Validate returns an error if the resource is invalid.
+func (r *AcceleratorTableResource) Validate() error {
+	if err := validateIdentifier(r.identity()); err != nil {
+		return err
+	} else if len(r.Entries) == 0 {
+		return errors.New("no accelerators given")
+	}
+	keys := make(map[[2]uint16]int)
+	for i, a := range r.Entries {
+		if err := a.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid accelerator #%d", i)
+		}
+		e, _ := a.entry()
+		k := [2]uint16{e.Flags &^ accel.NoInvertFlag, e.Key}
+		if j, ok := keys[k]; ok {
+			return errors.Errorf("accelerator #%d's key and accelerator #%d's key are same", i, j)
+		}
+		keys[k] = i
+	}
+	return nil
+}
+
+func (r *AcceleratorTableResource) identity() (int, string, *string) {
+	return r.ID, r.Name, r.Language
+}
+
+// Accelerator is a keystroke that sends a command.
+type Accelerator struct {
+	Key       string   // virtual-key name like "F5" or "VK_DELETE", or a single character
+	Modifiers []string // Ctrl, Shift, Alt, VirtKey or NoInvert
+	ID        int      // command id
+}
+
+// Validate returns an error if the accelerator is invalid.
+func (a *Accelerator) Validate() error {
+	if a.ID < 0 || a.ID > 0xffff {
+		return errors.Errorf("invalid id: %d", a.ID)
+	}
+	_, err := a.entry()
+	return err
+}
+
+func (a *Accelerator) entry() (*accel.Entry, error) {
+	return accel.NewEntry(a.Key, a.Modifiers, uint16(a.ID))
+}
+
+// EmbedAcceleratorTable embeds an accelerator table into c.
+func EmbedAcceleratorTable(c *coff.File, at *AcceleratorTableResource) error {
+	if err := at.Validate(); err != nil {
+		return errors.Wrap(err, "invalid accelerator table")
+	}
+	r, err := getOrCreateRSRCSection(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to get or create .rsrc section")
+	}
+	t := &accel.Table{}
+	for _, a := range at.Entries {
+		e, _ := a.entry()
+		t.Entries = append(t.Entries, e)
+	}
+	b := new(bytes.Buffer)
+	if _, err := t.WriteTo(b); err != nil {
+		return errors.Wrap(err, "failed to write accelerator table")
+	}
+	blob, err := common.NewBlob(b)
+	if err != nil {
+		return err
+	}
+	lang, _ := languageID(at.Language)
+	if err := r.AddResource(rsrc.AcceleratorResource, identifier(at.ID, at.Name), int(lang), blob); err != nil {
+		return errors.Wrap(err, "failed to add accelerator table resource")
+	}
+	return nil
+}
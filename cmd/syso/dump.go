@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"debug/pe"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/ico"
+	"github.com/hallazzang/syso/pkg/msgtable"
+	"github.com/hallazzang/syso/pkg/rsrc"
+	"github.com/hallazzang/syso/pkg/stringtable"
+	"github.com/hallazzang/syso/pkg/versioninfo"
+	"github.com/pkg/errors"
+)
+
+// dump prints resources in the object file or PE executable named name.
+func dump(w io.Writer, name string) error {
+	r, err := readRSRCSection(name)
+	if err != nil {
+		return err
+	}
+	for _, te := range r.Root().Entries() {
+		typ, isID := te.ID()
+		fmt.Fprintf(w, "%s\n", typeString(te))
+		if te.Subdirectory() == nil {
+			continue
+		}
+		for _, ne := range te.Subdirectory().Entries() {
+			id, _ := ne.ID()
+			fmt.Fprintf(w, "  %s\n", identifierString(ne))
+			if ne.Subdirectory() == nil {
+				continue
+			}
+			for _, le := range ne.Subdirectory().Entries() {
+				lang, _ := le.ID()
+				de := le.DataEntry()
+				if de == nil {
+					fmt.Fprintf(w, "    Language %#04x: not a data entry\n", lang)
+					continue
+				}
+				fmt.Fprintf(w, "    Language %#04x, Size %d, Codepage %d\n", lang, de.Data().Size(), de.Codepage())
+				if !isID {
+					continue
+				}
+				b, err := de.Data().Bytes()
+				if err != nil {
+					return err
+				}
+				dumpData(w, typ, id, b, "      ")
+			}
+		}
+	}
+	return nil
+}
+
+func readRSRCSection(name string) (*rsrc.Section, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open file")
+	}
+	defer f.Close()
+
+	var magic [2]byte
+	if _, err := f.ReadAt(magic[:], 0); err != nil {
+		return nil, errors.Wrap(err, "failed to read file")
+	}
+
+	if string(magic[:]) == "MZ" {
+		pf, err := pe.NewFile(f)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read PE file")
+		}
+		s := pf.Section(".rsrc")
+		if s == nil {
+			return nil, errors.New(".rsrc section not found")
+		}
+		data, err := s.Data()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read .rsrc section")
+		}
+		return rsrc.Decode(data, s.VirtualAddress)
+	}
+
+	c, err := coff.Parse(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read COFF file")
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get .rsrc section")
+	}
+	r, ok := s.(*rsrc.Section)
+	if !ok {
+		return nil, errors.New("the .rsrc section is not a valid rsrc section")
+	}
+	return r, nil
+}
+
+func typeString(e *rsrc.DirectoryEntry) string {
+	if name, ok := e.Name(); ok {
+		return fmt.Sprintf("Type %q", name)
+	}
+	typ, _ := e.ID()
+	if name := rsrc.TypeName(typ); name != "" {
+		return fmt.Sprintf("Type %s(%d)", name, typ)
+	}
+	return fmt.Sprintf("Type %d", typ)
+}
+
+func identifierString(e *rsrc.DirectoryEntry) string {
+	if name, ok := e.Name(); ok {
+		return fmt.Sprintf("Name %q", name)
+	}
+	id, _ := e.ID()
+	return fmt.Sprintf("ID %d", id)
+}
+
+// dumpData prints decoded content of known resource types. id is
+// the resource's id, or zero if it is named.
+func dumpData(w io.Writer, typ, id int, b []byte, indent string) {
+	switch typ {
+	case rsrc.StringResource:
+		ss, err := stringtable.DecodeBlock(id, b)
+		if err != nil {
+			fmt.Fprintf(w, "%sinvalid string table: %v\n", indent, err)
+			return
+		}
+		var ids []int
+		for sid := range ss {
+			ids = append(ids, int(sid))
+		}
+		sort.Ints(ids)
+		for _, sid := range ids {
+			fmt.Fprintf(w, "%sString ID %d: %q\n", indent, sid, ss[uint16(sid)])
+		}
+	case rsrc.IconGroupResource:
+		entries, err := ico.DecodeGroupResource(b)
+		if err != nil {
+			fmt.Fprintf(w, "%sinvalid icon group: %v\n", indent, err)
+			return
+		}
+		for _, e := range entries {
+			fmt.Fprintf(w, "%sIcon ID %d: %dx%d, %d bpp, %d bytes\n", indent, e.ID, e.Width, e.Height, e.BitCount, e.BytesInRes)
+		}
+	case rsrc.MessageTableResource:
+		t, err := msgtable.Decode(b)
+		if err != nil {
+			fmt.Fprintf(w, "%sinvalid message table: %v\n", indent, err)
+			return
+		}
+		for _, mid := range t.IDs() {
+			text, _ := t.Message(mid)
+			fmt.Fprintf(w, "%sMessage %#08x: %q\n", indent, mid, text)
+		}
+	case rsrc.VersionInfoResource:
+		vi, err := versioninfo.Decode(b)
+		if err != nil {
+			fmt.Fprintf(w, "%sinvalid version info: %v\n", indent, err)
+			return
+		}
+		fmt.Fprintf(w, "%sFileVersion: %s\n", indent, vi.FileVersionString())
+		fmt.Fprintf(w, "%sProductVersion: %s\n", indent, vi.ProductVersionString())
+		for _, st := range vi.StringTables() {
+			fmt.Fprintf(w, "%sStringTable %04x%04x\n", indent, st[0], st[1])
+			for _, k := range vi.Keys(st[0], st[1]) {
+				v, _ := vi.String(st[0], st[1], k)
+				fmt.Fprintf(w, "%s  %s: %q\n", indent, k, v)
+			}
+		}
+		for _, t := range vi.Translations() {
+			fmt.Fprintf(w, "%sTranslation %04x %04x\n", indent, t[0], t[1])
+		}
+	case rsrc.ManifestResource:
+		b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+		for _, line := range strings.Split(strings.TrimRight(string(b), "\r\n"), "\n") {
+			fmt.Fprintf(w, "%s%s\n", indent, strings.TrimRight(line, "\r"))
+		}
+	}
+}
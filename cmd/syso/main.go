@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/hallazzang/syso"
 	"github.com/hallazzang/syso/pkg/coff"
@@ -12,6 +13,8 @@ import (
 var (
 	configFile string
 	outFile    string
+	archs      string
+	verbose    bool
 )
 
 func printErrorAndExit(format string, arg ...interface{}) {
@@ -22,10 +25,22 @@ func printErrorAndExit(format string, arg ...interface{}) {
 func init() {
 	flag.StringVar(&configFile, "c", "syso.json", "config file name")
 	flag.StringVar(&outFile, "o", "out.syso", "output file name")
+	flag.BoolVar(&verbose, "v", false, "print ids assigned to icon and cursor images")
+	flag.StringVar(&archs, "a", "", "comma-separated list of target architectures(GOARCH); generates one file per architecture")
 	flag.Parse()
 }
 
 func main() {
+	if flag.Arg(0) == "dump" {
+		if flag.NArg() != 2 {
+			printErrorAndExit("usage: syso dump <file>\n")
+		}
+		if err := dump(os.Stdout, flag.Arg(1)); err != nil {
+			printErrorAndExit("failed to dump resources: %v\n", err)
+		}
+		return
+	}
+
 	fcfg, err := os.Open(configFile)
 	if err != nil {
 		printErrorAndExit("failed to open config file: %v\n", err)
@@ -37,35 +52,143 @@ func main() {
 		printErrorAndExit("failed to parse config: %v\n", err)
 	}
 
-	c := coff.New()
+	var goarchs []string
+	if archs != "" {
+		goarchs = strings.Split(archs, ",")
+	} else {
+		goarchs = cfg.Architectures
+	}
+
+	if len(goarchs) == 0 {
+		if err := generate(cfg, coff.MachineI386, outFile); err != nil {
+			printErrorAndExit("%v\n", err)
+		}
+		fmt.Printf("successfully generated syso file to %s\n", outFile)
+		return
+	}
+
+	for _, goarch := range goarchs {
+		m, err := coff.MachineFromGOARCH(strings.TrimSpace(goarch))
+		if err != nil {
+			printErrorAndExit("%v\n", err)
+		}
+		name := archOutFile(outFile, m.GOARCH())
+		if err := generate(cfg, m, name); err != nil {
+			printErrorAndExit("%v\n", err)
+		}
+		fmt.Printf("successfully generated syso file to %s\n", name)
+	}
+}
+
+// archOutFile returns output file name for goarch, following Go's build
+// constraint naming rule. For example, "rsrc.syso" with goarch "amd64"
+// becomes "rsrc_windows_amd64.syso".
+func archOutFile(name, goarch string) string {
+	return fmt.Sprintf("%s_windows_%s.syso", strings.TrimSuffix(name, ".syso"), goarch)
+}
+
+func generate(cfg *syso.Config, m coff.Machine, name string) error {
+	c := coff.New(m)
 
 	for i, icon := range cfg.Icons {
-		if err := syso.EmbedIcon(c, icon); err != nil {
-			printErrorAndExit("failed to embed icon #%d: %v\n", i, err)
+		if err := syso.EmbedIconResource(c, icon); err != nil {
+			return fmt.Errorf("failed to embed icon #%d: %v", i, err)
+		}
+		if verbose {
+			fmt.Printf("icon #%d's image ids: %v\n", i, icon.AssignedImageIDs())
+		}
+	}
+
+	for i, cursor := range cfg.Cursors {
+		if err := syso.EmbedCursorResource(c, cursor); err != nil {
+			return fmt.Errorf("failed to embed cursor #%d: %v", i, err)
+		}
+		if verbose {
+			fmt.Printf("cursor #%d's image ids: %v\n", i, cursor.AssignedImageIDs())
+		}
+	}
+
+	for i, cursor := range cfg.AnimatedCursors {
+		if err := syso.EmbedAnimatedCursor(c, cursor); err != nil {
+			return fmt.Errorf("failed to embed animated cursor #%d: %v", i, err)
+		}
+	}
+
+	for i, icon := range cfg.AnimatedIcons {
+		if err := syso.EmbedAnimatedIcon(c, icon); err != nil {
+			return fmt.Errorf("failed to embed animated icon #%d: %v", i, err)
+		}
+	}
+
+	for i, bitmap := range cfg.Bitmaps {
+		if err := syso.EmbedBitmap(c, bitmap); err != nil {
+			return fmt.Errorf("failed to embed bitmap #%d: %v", i, err)
 		}
 	}
 
 	if cfg.Manifest != nil {
-		if err := syso.EmbedManifest(c, cfg.Manifest); err != nil {
-			printErrorAndExit("failed to embed manifest: %v\n", err)
+		if err := syso.EmbedManifestResource(c, cfg.Manifest); err != nil {
+			return fmt.Errorf("failed to embed manifest: %v", err)
 		}
 	}
 
 	for i, vi := range cfg.VersionInfos {
 		if err := syso.EmbedVersionInfo(c, vi); err != nil {
-			printErrorAndExit("failed to embed version info #%d: %v\n", i, err)
+			return fmt.Errorf("failed to embed version info #%d: %v", i, err)
+		}
+	}
+
+	for i, st := range cfg.StringTables {
+		if err := syso.EmbedStringTable(c, st); err != nil {
+			return fmt.Errorf("failed to embed string table #%d: %v", i, err)
+		}
+	}
+
+	for i, mt := range cfg.MessageTables {
+		if err := syso.EmbedMessageTable(c, mt); err != nil {
+			return fmt.Errorf("failed to embed message table #%d: %v", i, err)
+		}
+	}
+
+	for i, dlg := range cfg.Dialogs {
+		if err := syso.EmbedDialog(c, dlg); err != nil {
+			return fmt.Errorf("failed to embed dialog #%d: %v", i, err)
+		}
+	}
+
+	for i, m := range cfg.Menus {
+		if err := syso.EmbedMenu(c, m); err != nil {
+			return fmt.Errorf("failed to embed menu #%d: %v", i, err)
+		}
+	}
+
+	for i, at := range cfg.Accelerators {
+		if err := syso.EmbedAcceleratorTable(c, at); err != nil {
+			return fmt.Errorf("failed to embed accelerator table #%d: %v", i, err)
+		}
+	}
+
+	for i, res := range cfg.Resources {
+		if err := syso.EmbedResource(c, res); err != nil {
+			return fmt.Errorf("failed to embed resource #%d: %v", i, err)
+		}
+	}
+
+	for i, dir := range cfg.Directories {
+		if err := syso.EmbedDirectory(c, dir); err != nil {
+			return fmt.Errorf("failed to embed directory #%d: %v", i, err)
 		}
 	}
 
-	fout, err := os.Create(outFile)
+	fout, err := os.Create(name)
 	if err != nil {
-		panic(err)
+		return fmt.Errorf("failed to create output file: %v", err)
 	}
 	defer fout.Close()
 
 	if _, err := c.WriteTo(fout); err != nil {
-		panic(err)
+		return fmt.Errorf("failed to write output file: %v", err)
 	}
 
-	fmt.Printf("successfully generated syso file to %s", outFile)
+	return nil
 }
@@ -0,0 +1,229 @@
+package syso
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/hallazzang/syso/pkg/dialog"
+	"github.com/hallazzang/syso/pkg/rsrc"
+	"github.com/pkg/errors"
+)
+
+// Style is a window style. In JSON, it is written as a number or
+// a string of style names and numbers combined with "|", like
+// "WS_POPUP | WS_CAPTION | DS_MODALFRAME".
+type Style uint32
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Style) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		if v < 0 || v > 0xffffffff || v != float64(int64(v)) {
+			return errors.Errorf("invalid style: %v", v)
+		}
+		*s = Style(v)
+	case string:
+		style, err := dialog.ParseStyle(v)
+		if err != nil {
+			return err
+		}
+		*s = Style(style)
+	default:
+		return errors.Errorf("style must be a number or a string, got %s", b)
+	}
+	return nil
+}
+
+// default styles, as resource compiler does
+const (
+	defaultDialogStyle  = 0x80880000 // WS_POPUP | WS_BORDER | WS_SYSMENU
+	captionStyle        = 0x00c00000 // WS_CAPTION
+	childStyle          = 0x40000000 // WS_CHILD
+	defaultControlStyle = 0x10000000 // WS_VISIBLE
+)
+
+// DialogResource represents a dialog template resource. Coordinates
+// and sizes are in dialog units.
+type DialogResource struct {
+	ID       int
+	Name     string
+	Language *string
+	Style    *Style // default WS_POPUP | WS_BORDER | WS_SYSMENU, and WS_CAPTION if Caption is given
+	ExStyle  Style
+	X        int
+	Y        int
+	Width    int
+	Height   int
+	Caption  string
+	Font     *DialogFont
+	Controls []*DialogControl
+}
+
+// Validate returns an error if the resource is invalid.
+func (r *DialogResource) Validate() error {
+	if err := validateIdentifier(r.identity()); err != nil {
+		return err
+	} else if err := validateRect(r.X, r.Y, r.Width, r.Height); err != nil {
+		return err
+	}
+	if r.Font != nil {
+		if err := r.Font.Validate(); err != nil {
+			return errors.Wrap(err, "invalid font")
+		}
+	}
+	if len(r.Controls) > 0xffff {
+		return errors.Errorf("too many controls: %d", len(r.Controls))
+	}
+	for i, ctl := range r.Controls {
+		if err := ctl.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid control #%d", i)
+		}
+	}
+	return nil
+}
+
+func (r *DialogResource) identity() (int, string, *string) {
+	return r.ID, r.Name, r.Language
+}
+
+func (r *DialogResource) template() *dialog.Template {
+	style := uint32(defaultDialogStyle)
+	if r.Style != nil {
+		style = uint32(*r.Style)
+	} else if r.Caption != "" {
+		style |= captionStyle
+	}
+	t := &dialog.Template{
+		ExStyle: uint32(r.ExStyle),
+		Style:   style,
+		X:       int16(r.X),
+		Y:       int16(r.Y),
+		Width:   int16(r.Width),
+		Height:  int16(r.Height),
+		Title:   r.Caption,
+	}
+	if r.Font != nil {
+		t.Font = &dialog.Font{
+			PointSize: uint16(r.Font.Size),
+			Weight:    uint16(r.Font.Weight),
+			Italic:    r.Font.Italic,
+			Charset:   uint8(r.Font.charset()),
+			Typeface:  r.Font.Typeface,
+		}
+	}
+	for _, ctl := range r.Controls {
+		style := uint32(defaultControlStyle)
+		if ctl.Style != nil {
+			style = uint32(*ctl.Style)
+		}
+		t.Controls = append(t.Controls, &dialog.Control{
+			ExStyle: uint32(ctl.ExStyle),
+			Style:   style | childStyle,
+			X:       int16(ctl.X),
+			Y:       int16(ctl.Y),
+			Width:   int16(ctl.Width),
+			Height:  int16(ctl.Height),
+			ID:      uint32(ctl.ID), // -1 becomes 0xffffffff
+			Class:   ctl.Class,
+			Title:   ctl.Text,
+		})
+	}
+	return t
+}
+
+// DialogFont is the font of a dialog and its controls.
+type DialogFont struct {
+	Typeface string
+	Size     int // in points
+	Weight   int
+	Italic   bool
+	Charset  *int // default 1(DEFAULT_CHARSET)
+}
+
+// Validate returns an error if the font is invalid.
+func (f *DialogFont) Validate() error {
+	if f.Typeface == "" {
+		return errors.New("no typeface given")
+	} else if f.Size < 1 || f.Size > 0xffff {
+		return errors.Errorf("invalid size: %d", f.Size)
+	} else if f.Weight < 0 || f.Weight > 1000 {
+		return errors.Errorf("invalid weight: %d", f.Weight)
+	} else if c := f.charset(); c < 0 || c > 0xff {
+		return errors.Errorf("invalid charset: %d", c)
+	}
+	return nil
+}
+
+func (f *DialogFont) charset() int {
+	if f.Charset == nil {
+		return 1
+	}
+	return *f.Charset
+}
+
+// DialogControl is a control in a dialog.
+type DialogControl struct {
+	Class   string // BUTTON, EDIT, STATIC, LISTBOX, SCROLLBAR, COMBOBOX or other registered class name
+	Text    string
+	ID      int    // -1 for IDC_STATIC
+	Style   *Style // default WS_VISIBLE; WS_CHILD is always added
+	ExStyle Style
+	X       int
+	Y       int
+	Width   int
+	Height  int
+}
+
+// Validate returns an error if the control is invalid.
+func (c *DialogControl) Validate() error {
+	if c.Class == "" {
+		return errors.New("no class given")
+	} else if c.ID < -1 || c.ID > 0xffff {
+		return errors.Errorf("invalid id: %d", c.ID)
+	}
+	return validateRect(c.X, c.Y, c.Width, c.Height)
+}
+
+// validateRect returns an error if the coordinates or sizes don't fit
+// in 16-bit integers.
+func validateRect(x, y, width, height int) error {
+	for _, v := range []int{x, y, width, height} {
+		if v < -0x8000 || v > 0x7fff {
+			return errors.Errorf("invalid coordinate or size: %d", v)
+		}
+	}
+	if width < 0 || height < 0 {
+		return errors.Errorf("invalid size: %dx%d", width, height)
+	}
+	return nil
+}
+
+// EmbedDialog embeds a dialog template into c.
+func EmbedDialog(c *coff.File, dlg *DialogResource) error {
+	if err := dlg.Validate(); err != nil {
+		return errors.Wrap(err, "invalid dialog")
+	}
+	r, err := getOrCreateRSRCSection(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to get or create .rsrc section")
+	}
+	b := new(bytes.Buffer)
+	if _, err := dlg.template().WriteTo(b); err != nil {
+		return errors.Wrap(err, "failed to write dialog template")
+	}
+	blob, err := common.NewBlob(b)
+	if err != nil {
+		return err
+	}
+	lang, _ := languageID(dlg.Language)
+	if err := r.AddResource(rsrc.DialogResource, identifier(dlg.ID, dlg.Name), int(lang), blob); err != nil {
+		return errors.Wrap(err, "failed to add dialog resource")
+	}
+	return nil
+}
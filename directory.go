@@ -0,0 +1,150 @@
+package syso
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/hallazzang/syso/pkg/rsrc"
+	"github.com/pkg/errors"
+)
+
+const defaultIndexName = "_INDEX"
+
+// DirectoryResource represents files in a directory, each of which is
+// embedded as a resource named after its path relative to Path.
+type DirectoryResource struct {
+	Path      string
+	Pattern   *string       // default "*"
+	Type      *ResourceType // default RT_RCDATA
+	Language  *string
+	IndexName *string // default "_INDEX"
+}
+
+// Validate returns an error if the resource is invalid.
+func (r *DirectoryResource) Validate() error {
+	if r.Path == "" {
+		return errors.New("no directory path given")
+	} else if _, err := path.Match(r.pattern(), ""); err != nil {
+		return errors.Wrapf(err, "invalid pattern: %q", r.pattern())
+	} else if r.IndexName != nil && *r.IndexName == "" {
+		return errors.New("index name cannot be empty")
+	} else if _, err := languageID(r.Language); err != nil {
+		return errors.Wrap(err, "failed to parse language identifier")
+	}
+	if r.Type != nil {
+		if err := r.Type.Validate(); err != nil {
+			return errors.Wrap(err, "invalid resource type")
+		}
+	}
+	return nil
+}
+
+func (r *DirectoryResource) pattern() string {
+	if r.Pattern == nil {
+		return "*"
+	}
+	return *r.Pattern
+}
+
+func (r *DirectoryResource) resourceType() ResourceType {
+	if r.Type == nil {
+		return ResourceType{ID: rsrc.RCDataResource}
+	}
+	return *r.Type
+}
+
+func (r *DirectoryResource) indexName() string {
+	if r.IndexName == nil {
+		return defaultIndexName
+	}
+	return *r.IndexName
+}
+
+// match reports whether the file at slash-separated relative path rel
+// matches the pattern. A pattern without a slash is matched against
+// the file's base name, so that "*.js" matches files in subdirectories.
+func (r *DirectoryResource) match(rel string) bool {
+	p := r.pattern()
+	if !strings.Contains(p, "/") {
+		rel = path.Base(rel)
+	}
+	ok, _ := path.Match(p, rel)
+	return ok
+}
+
+// files returns slash-separated relative paths of matching files, in
+// lexical order.
+func (r *DirectoryResource) files() ([]string, error) {
+	var files []string
+	if err := filepath.Walk(r.Path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(r.Path, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if r.match(rel) {
+			files = append(files, rel)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// EmbedDirectory embeds files in a directory into c, along with an
+// index resource that lists their relative paths, one per line.
+// Each resource is named after its upper-cased relative path, as
+// Windows looks up resource names case-insensitively.
+func EmbedDirectory(c *coff.File, dir *DirectoryResource) error {
+	if err := dir.Validate(); err != nil {
+		return errors.Wrap(err, "invalid directory resource")
+	}
+	r, err := getOrCreateRSRCSection(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to get or create .rsrc section")
+	}
+	files, err := dir.files()
+	if err != nil {
+		return errors.Wrap(err, "failed to list files")
+	}
+	typ := dir.resourceType()
+	lang, _ := languageID(dir.Language)
+	for _, rel := range files {
+		if err := embedDirectoryFile(r, typ, int(lang), filepath.Join(dir.Path, filepath.FromSlash(rel)), rel); err != nil {
+			return errors.Wrapf(err, "failed to embed file %q", rel)
+		}
+	}
+	var index bytes.Buffer
+	for _, rel := range files {
+		index.WriteString(rel + "\n")
+	}
+	if err := r.AddResource(typ.identifier(), strings.ToUpper(dir.indexName()), int(lang), common.NewBlobFromBytes(index.Bytes())); err != nil {
+		return errors.Wrap(err, "failed to add index resource")
+	}
+	return nil
+}
+
+func embedDirectoryFile(r *rsrc.Section, typ ResourceType, lang int, name, rel string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return errors.Wrap(err, "failed to open file")
+	}
+	defer f.Close()
+	b, err := common.NewBlob(f)
+	if err != nil {
+		return err
+	}
+	return r.AddResource(typ.identifier(), strings.ToUpper(rel), lang, b)
+}
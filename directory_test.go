@@ -0,0 +1,61 @@
+package syso
+
+import (
+	"testing"
+
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/rsrc"
+)
+
+func TestEmbedDirectory(t *testing.T) {
+	pattern := "*.ico"
+	c := coff.New(coff.MachineAMD64)
+	if err := EmbedDirectory(c, &DirectoryResource{
+		Path:    "testdata",
+		Pattern: &pattern,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := s.(*rsrc.Section)
+	for _, name := range []string{"ICON.ICO", "GOLANG.ICO", defaultIndexName} {
+		if !r.ResourceNameExists(name) {
+			t.Fatalf("resource %q not found", name)
+		}
+	}
+	for _, e := range r.Root().Entries()[0].Subdirectory().Entries() {
+		if name, _ := e.Name(); name != defaultIndexName {
+			continue
+		}
+		b, err := e.Subdirectory().Entries()[0].DataEntry().Data().Bytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "golang.ico\nicon.ico\n" {
+			t.Fatalf("wrong index; got %q", b)
+		}
+	}
+}
+
+func TestDirectoryResource_match(t *testing.T) {
+	for _, tc := range []struct {
+		Pattern string
+		Path    string
+		Match   bool
+	}{
+		{"*", "a/b.js", true},
+		{"*.js", "a/b.js", true},
+		{"*.js", "a/b.css", false},
+		{"a/*.js", "a/b.js", true},
+		{"a/*.js", "c/b.js", false},
+	} {
+		pattern := tc.Pattern
+		r := &DirectoryResource{Path: ".", Pattern: &pattern}
+		if m := r.match(tc.Path); m != tc.Match {
+			t.Fatalf("wrong match result for %q and %q; expected %v, got %v", tc.Pattern, tc.Path, tc.Match, m)
+		}
+	}
+}
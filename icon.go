@@ -0,0 +1,168 @@
+package syso
+
+import (
+	"image"
+	_ "image/png" // register PNG format
+	"os"
+
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/ico"
+	"github.com/hallazzang/syso/pkg/rsrc"
+	"github.com/pkg/errors"
+)
+
+// IconResource represents an icon resource, which is either an ICO
+// file at Path, a set of PNG images at Images, or a single large image
+// at Source that is resampled to Sizes.
+type IconResource struct {
+	FileResource
+	ImageIDs *IDRange // id range for icon images, default DefaultImageIDs
+	Images   []string
+	Source   string
+	Sizes    []int // default ico.DefaultSizes
+
+	imageIDs []int
+}
+
+// AssignedImageIDs returns ids assigned to the icon's images by the
+// last EmbedIconResource call.
+func (r *IconResource) AssignedImageIDs() []int {
+	return r.imageIDs
+}
+
+// Validate returns an error if the resource is invalid.
+func (r *IconResource) Validate() error {
+	if r.ImageIDs != nil {
+		if err := r.ImageIDs.Validate(); err != nil {
+			return errors.Wrap(err, "invalid image id range")
+		}
+	}
+	if len(r.Images) == 0 && r.Source == "" {
+		if len(r.Sizes) > 0 {
+			return errors.New("sizes can be set only with source")
+		}
+		return r.FileResource.Validate()
+	}
+	if r.Path != "" {
+		return errors.New("path cannot be set with images or source")
+	} else if len(r.Images) > 0 && r.Source != "" {
+		return errors.New("images and source cannot be set together")
+	} else if len(r.Images) > 0 && len(r.Sizes) > 0 {
+		return errors.New("sizes can be set only with source")
+	}
+	for i, path := range r.Images {
+		if path == "" {
+			return errors.Errorf("no file path given for image #%d", i)
+		}
+	}
+	for _, size := range r.Sizes {
+		if size < 1 || size > 256 {
+			return errors.Errorf("invalid icon size: %d", size)
+		}
+	}
+	return validateIdentifier(r.identity())
+}
+
+// group returns the icon group that the resource describes.
+func (r *IconResource) group() (*ico.Group, error) {
+	if r.Source != "" {
+		img, err := decodeImage(r.Source)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode source image")
+		}
+		g, err := ico.NewGroupFromImage(img, r.Sizes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create icon group")
+		}
+		return g, nil
+	} else if len(r.Images) == 0 {
+		f, err := os.Open(r.Path)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open icon file")
+		}
+		defer f.Close()
+		g, err := ico.DecodeAll(f)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode icon file")
+		}
+		return g, nil
+	}
+	var imgs []image.Image
+	for i, path := range r.Images {
+		img, err := decodeImage(path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode image #%d", i)
+		}
+		imgs = append(imgs, img)
+	}
+	g, err := ico.NewGroup(imgs)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create icon group")
+	}
+	return g, nil
+}
+
+func decodeImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open image file")
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// EmbedIcon embeds an icon file into c. Ids for icon images are
+// allocated in DefaultImageIDs.
+func EmbedIcon(c *coff.File, icon *FileResource) error {
+	return EmbedIconResource(c, &IconResource{FileResource: *icon})
+}
+
+// EmbedIconResource embeds an icon into c. Ids for icon images are
+// allocated in icon.ImageIDs, and can be retrieved by
+// icon.AssignedImageIDs.
+func EmbedIconResource(c *coff.File, icon *IconResource) error {
+	if err := icon.Validate(); err != nil {
+		return errors.Wrap(err, "invalid icon")
+	}
+	r, err := getOrCreateRSRCSection(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to get or create .rsrc section")
+	}
+	icons, err := icon.group()
+	if err != nil {
+		return err
+	}
+	lang, _ := languageID(icon.Language)
+	ids := icon.ImageIDs
+	if ids == nil {
+		ids = DefaultImageIDs
+	}
+	var assigned []int
+	for i, img := range icons.Images {
+		// identical images are stored once and shared between groups,
+		// if they are in the id range
+		if id, ok := r.FindResourceID(rsrc.IconResource, int(lang), img.Bytes()); ok && ids.contains(id) {
+			img.ID = id
+			assigned = append(assigned, id)
+			continue
+		}
+		img.ID, err = allocateImageID(r, rsrc.IconResource, ids)
+		if err != nil {
+			return errors.Wrapf(err, "failed to allocate id for icon image #%d", i)
+		}
+		if err := r.AddResource(rsrc.IconResource, img.ID, int(lang), img); err != nil {
+			return errors.Wrapf(err, "failed to add icon image #%d", i)
+		}
+		r.MarkShareable(rsrc.IconResource, img.ID)
+		assigned = append(assigned, img.ID)
+	}
+	if err := r.AddResource(rsrc.IconGroupResource, identifier(icon.ID, icon.Name), int(lang), icons); err != nil {
+		return errors.Wrap(err, "failed to add icon group resource")
+	}
+	icon.imageIDs = assigned
+	return nil
+}
@@ -0,0 +1,150 @@
+package syso
+
+import (
+	"io/ioutil"
+
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/hallazzang/syso/pkg/manifest"
+	"github.com/hallazzang/syso/pkg/rsrc"
+	"github.com/pkg/errors"
+)
+
+// ManifestResource represents an application manifest. The manifest is
+// read from a file at Path, or generated from the other fields if Path
+// is empty.
+type ManifestResource struct {
+	FileResource
+	Identity       *ManifestIdentity
+	ExecutionLevel string // default asInvoker
+	UIAccess       bool
+	DPIAwareness   string // unaware, system, permonitor or permonitorv2
+	LongPathAware  bool
+	UTF8           bool     // use UTF-8 as active code page
+	SupportedOS    []string // vista, 7, 8, 8.1 or 10; default all of them
+	CommonControls bool     // depend on Common Controls version 6
+}
+
+// ManifestIdentity is the assembly identity of an application.
+type ManifestIdentity struct {
+	Name    string
+	Version string
+}
+
+// Validate returns an error if the resource is invalid.
+func (r *ManifestResource) Validate() error {
+	if err := validateIdentifier(r.identity()); err != nil {
+		return err
+	}
+	if r.Path != "" {
+		if r.hasOptions() {
+			return errors.New("path and generation options cannot be set together")
+		}
+		return nil
+	}
+	return r.options().Validate()
+}
+
+func (r *ManifestResource) hasOptions() bool {
+	return r.Identity != nil || r.ExecutionLevel != "" || r.UIAccess || r.DPIAwareness != "" ||
+		r.LongPathAware || r.UTF8 || r.SupportedOS != nil || r.CommonControls
+}
+
+func (r *ManifestResource) options() *manifest.Options {
+	o := &manifest.Options{
+		ExecutionLevel: r.ExecutionLevel,
+		UIAccess:       r.UIAccess,
+		DPIAwareness:   r.DPIAwareness,
+		LongPathAware:  r.LongPathAware,
+		UTF8:           r.UTF8,
+		SupportedOS:    r.SupportedOS,
+		CommonControls: r.CommonControls,
+	}
+	if r.Identity != nil {
+		o.Name, o.Version = r.Identity.Name, r.Identity.Version
+	}
+	if o.ExecutionLevel == "" {
+		o.ExecutionLevel = "asInvoker"
+	}
+	if o.SupportedOS == nil {
+		o.SupportedOS = manifest.AllSupportedOS
+	}
+	return o
+}
+
+// inheritIdentity fills missing identity name and version from vi.
+// Name is taken from InternalName or ProductName, and version from
+// FileVersion or ProductVersion.
+func (r *ManifestResource) inheritIdentity(vi *VersionInfoResource) {
+	if r.Identity == nil {
+		r.Identity = &ManifestIdentity{}
+	}
+	if r.Identity.Name == "" {
+		for _, st := range vi.StringTables {
+			if st.Strings == nil {
+				continue
+			} else if s := st.Strings.InternalName; s != nil && *s != "" {
+				r.Identity.Name = *s
+			} else if s := st.Strings.ProductName; s != nil && *s != "" {
+				r.Identity.Name = *s
+			} else {
+				continue
+			}
+			break
+		}
+	}
+	if r.Identity.Version == "" && vi.Fixed != nil {
+		if vi.Fixed.FileVersion != nil {
+			r.Identity.Version = *vi.Fixed.FileVersion
+		} else if vi.Fixed.ProductVersion != nil {
+			r.Identity.Version = *vi.Fixed.ProductVersion
+		}
+	}
+	if r.Identity.Name == "" {
+		r.Identity = nil
+	}
+}
+
+// EmbedManifest embeds a manifest file into c. The file is validated
+// and its UTF-8 byte order mark is stripped before embedding.
+func EmbedManifest(c *coff.File, m *FileResource) error {
+	if err := m.Validate(); err != nil {
+		return errors.Wrap(err, "invalid manifest")
+	}
+	return EmbedManifestResource(c, &ManifestResource{FileResource: *m})
+}
+
+// EmbedManifestResource embeds a manifest into c, which is read from a
+// file like EmbedManifest, or generated from m's options.
+func EmbedManifestResource(c *coff.File, m *ManifestResource) error {
+	if err := m.Validate(); err != nil {
+		return errors.Wrap(err, "invalid manifest")
+	}
+	r, err := getOrCreateRSRCSection(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to get or create .rsrc section")
+	}
+	var b common.Blob
+	if m.Path != "" {
+		data, err := ioutil.ReadFile(m.Path)
+		if err != nil {
+			return errors.Wrap(err, "failed to read manifest file")
+		}
+		data = manifest.StripBOM(data)
+		if err := manifest.Validate(data); err != nil {
+			return errors.Wrap(err, "invalid manifest file")
+		}
+		b = common.NewBlobFromBytes(data)
+	} else {
+		data, err := manifest.Generate(m.options())
+		if err != nil {
+			return errors.Wrap(err, "failed to generate manifest")
+		}
+		b = common.NewBlobFromBytes(data)
+	}
+	lang, _ := languageID(m.Language)
+	if err := r.AddResource(rsrc.ManifestResource, identifier(m.ID, m.Name), int(lang), b); err != nil {
+		return errors.Wrap(err, "failed to add manifest resource")
+	}
+	return nil
+}
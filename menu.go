@@ -0,0 +1,114 @@
+package syso
+
+import (
+	"bytes"
+
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/hallazzang/syso/pkg/menu"
+	"github.com/hallazzang/syso/pkg/rsrc"
+	"github.com/pkg/errors"
+)
+
+// MenuResource represents a menu resource, which is either a menu bar
+// or a set of popup menus that can be taken with GetSubMenu.
+type MenuResource struct {
+	ID       int
+	Name     string
+	Language *string
+	Items    []*MenuItem
+}
+
+// Validate returns an error if the resource is invalid.
+func (r *MenuResource) Validate() error {
+	if err := validateIdentifier(r.identity()); err != nil {
+		return err
+	} else if len(r.Items) == 0 {
+		return errors.New("no menu items given")
+	}
+	return validateMenuItems(r.Items)
+}
+
+func validateMenuItems(items []*MenuItem) error {
+	for i, item := range items {
+		if err := item.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid menu item #%d", i)
+		}
+	}
+	return nil
+}
+
+func (r *MenuResource) identity() (int, string, *string) {
+	return r.ID, r.Name, r.Language
+}
+
+func (r *MenuResource) menu() *menu.Menu {
+	return &menu.Menu{
+		Items: menuItems(r.Items),
+	}
+}
+
+func menuItems(items []*MenuItem) []*menu.Item {
+	var r []*menu.Item
+	for _, item := range items {
+		typ, state, _ := menu.ParseFlags(item.flags())
+		r = append(r, &menu.Item{
+			Type:  typ,
+			State: state,
+			ID:    uint32(item.ID),
+			Text:  item.Text,
+			Items: menuItems(item.Items),
+		})
+	}
+	return r
+}
+
+// MenuItem is an item in a menu. An item that has sub items opens
+// a submenu.
+type MenuItem struct {
+	Text  string
+	ID    int    // command id
+	Flags string // like "MFT_RADIOCHECK | MFS_CHECKED"
+	Items []*MenuItem
+}
+
+// Validate returns an error if the menu item is invalid.
+func (item *MenuItem) Validate() error {
+	if item.ID < 0 || item.ID > 0xffff {
+		return errors.Errorf("invalid id: %d", item.ID)
+	} else if _, _, err := menu.ParseFlags(item.flags()); err != nil {
+		return errors.Wrap(err, "invalid flags")
+	}
+	return validateMenuItems(item.Items)
+}
+
+func (item *MenuItem) flags() string {
+	if item.Flags == "" {
+		return "MFT_STRING"
+	}
+	return item.Flags
+}
+
+// EmbedMenu embeds a menu into c.
+func EmbedMenu(c *coff.File, m *MenuResource) error {
+	if err := m.Validate(); err != nil {
+		return errors.Wrap(err, "invalid menu")
+	}
+	r, err := getOrCreateRSRCSection(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to get or create .rsrc section")
+	}
+	b := new(bytes.Buffer)
+	if _, err := m.menu().WriteTo(b); err != nil {
+		return errors.Wrap(err, "failed to write menu template")
+	}
+	blob, err := common.NewBlob(b)
+	if err != nil {
+		return err
+	}
+	lang, _ := languageID(m.Language)
+	if err := r.AddResource(rsrc.MenuResource, identifier(m.ID, m.Name), int(lang), blob); err != nil {
+		return errors.Wrap(err, "failed to add menu resource")
+	}
+	return nil
+}
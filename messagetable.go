@@ -0,0 +1,132 @@
+package syso
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/hallazzang/syso/pkg/msgtable"
+	"github.com/hallazzang/syso/pkg/rsrc"
+	"github.com/pkg/errors"
+)
+
+// MessageTableResource represents a message table resource, used by
+// the Windows Event Log to format event messages. Messages are either
+// given in Messages, or read from a message text(.mc) file at Path,
+// in which case the languages defined in the file are used.
+type MessageTableResource struct {
+	FileResource
+	Messages []*Message
+}
+
+// Validate returns an error if the resource is invalid.
+func (r *MessageTableResource) Validate() error {
+	if r.Path == "" && len(r.Messages) == 0 {
+		return errors.New("neither path nor messages given")
+	} else if r.Path != "" && len(r.Messages) > 0 {
+		return errors.New("path and messages cannot be set together")
+	} else if r.Path != "" && r.Language != nil {
+		return errors.New("language cannot be set with path; languages are defined in the file")
+	}
+	if err := validateIdentifier(r.identity()); err != nil {
+		return err
+	}
+	ids := make(map[uint32]int)
+	for i, m := range r.Messages {
+		if err := m.Validate(); err != nil {
+			return errors.Wrapf(err, "invalid message #%d", i)
+		}
+		id := m.messageID()
+		if j, ok := ids[id]; ok {
+			return errors.Errorf("message #%d's id and message #%d's id are same", i, j)
+		}
+		ids[id] = i
+	}
+	return nil
+}
+
+// tables returns message tables by their language ids.
+func (r *MessageTableResource) tables() (map[uint16]*msgtable.Table, error) {
+	if r.Path != "" {
+		f, err := os.Open(r.Path)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to open message text file")
+		}
+		defer f.Close()
+		tables, err := msgtable.ParseMC(f)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse message text file")
+		} else if len(tables) == 0 {
+			return nil, errors.New("message text file has no messages")
+		}
+		return tables, nil
+	}
+	t := msgtable.New()
+	for _, m := range r.Messages {
+		t.SetMessage(m.messageID(), m.Text)
+	}
+	lang, _ := languageID(r.Language)
+	return map[uint16]*msgtable.Table{lang: t}, nil
+}
+
+// Message is a message in a message table. Its message id is composed
+// of Severity, Facility and ID, like message compiler does.
+type Message struct {
+	ID       int
+	Severity *string // Success(default), Informational, Warning or Error
+	Facility int
+	Text     string
+}
+
+// Validate returns an error if the message is invalid.
+func (m *Message) Validate() error {
+	if m.ID < 0 || m.ID > 0xffff {
+		return errors.Errorf("invalid id: %d", m.ID)
+	} else if m.Facility < 0 || m.Facility > 0xfff {
+		return errors.Errorf("invalid facility: %d", m.Facility)
+	} else if m.Severity != nil {
+		if _, ok := msgtable.Severity(*m.Severity); !ok {
+			return errors.Errorf("invalid severity: %q", *m.Severity)
+		}
+	}
+	return nil
+}
+
+func (m *Message) messageID() uint32 {
+	sev := msgtable.SeveritySuccess
+	if m.Severity != nil {
+		sev, _ = msgtable.Severity(*m.Severity)
+	}
+	return msgtable.MessageID(sev, m.Facility, m.ID)
+}
+
+// EmbedMessageTable embeds a message table into c, one resource for
+// each language.
+func EmbedMessageTable(c *coff.File, mt *MessageTableResource) error {
+	if err := mt.Validate(); err != nil {
+		return errors.Wrap(err, "invalid message table")
+	}
+	r, err := getOrCreateRSRCSection(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to get or create .rsrc section")
+	}
+	tables, err := mt.tables()
+	if err != nil {
+		return err
+	}
+	for lang, t := range tables {
+		b := new(bytes.Buffer)
+		if _, err := t.WriteTo(b); err != nil {
+			return errors.Wrap(err, "failed to write message table")
+		}
+		blob, err := common.NewBlob(b)
+		if err != nil {
+			return err
+		}
+		if err := r.AddResource(rsrc.MessageTableResource, identifier(mt.ID, mt.Name), int(lang), blob); err != nil {
+			return errors.Wrapf(err, "failed to add message table resource for language %#04x", lang)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package accel provides accelerator table(RT_ACCELERATOR) resource
+// related functionalities.
+package accel
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/pkg/errors"
+)
+
+// flags in ACCELTABLEENTRY
+const (
+	VirtKeyFlag  = 0x01 // FVIRTKEY
+	NoInvertFlag = 0x02 // FNOINVERT
+	ShiftFlag    = 0x04 // FSHIFT
+	ControlFlag  = 0x08 // FCONTROL
+	AltFlag      = 0x10 // FALT
+	lastFlag     = 0x80
+)
+
+var modifierFlags = map[string]uint16{
+	"virtkey":  VirtKeyFlag,
+	"noinvert": NoInvertFlag,
+	"shift":    ShiftFlag,
+	"ctrl":     ControlFlag,
+	"control":  ControlFlag,
+	"alt":      AltFlag,
+}
+
+// virtual-key codes by their names without "VK_" prefix
+var virtualKeys = map[string]uint16{
+	"BACK":     0x08,
+	"TAB":      0x09,
+	"CLEAR":    0x0c,
+	"RETURN":   0x0d,
+	"ENTER":    0x0d,
+	"PAUSE":    0x13,
+	"ESCAPE":   0x1b,
+	"ESC":      0x1b,
+	"SPACE":    0x20,
+	"PRIOR":    0x21,
+	"PAGEUP":   0x21,
+	"NEXT":     0x22,
+	"PAGEDOWN": 0x22,
+	"END":      0x23,
+	"HOME":     0x24,
+	"LEFT":     0x25,
+	"UP":       0x26,
+	"RIGHT":    0x27,
+	"DOWN":     0x28,
+	"SNAPSHOT": 0x2c,
+	"INSERT":   0x2d,
+	"DELETE":   0x2e,
+	"HELP":     0x2f,
+	"APPS":     0x5d,
+	"MULTIPLY": 0x6a,
+	"ADD":      0x6b,
+	"SUBTRACT": 0x6d,
+	"DECIMAL":  0x6e,
+	"DIVIDE":   0x6f,
+
+	"OEM_1":      0xba,
+	"OEM_PLUS":   0xbb,
+	"OEM_COMMA":  0xbc,
+	"OEM_MINUS":  0xbd,
+	"OEM_PERIOD": 0xbe,
+	"OEM_2":      0xbf,
+	"OEM_3":      0xc0,
+	"OEM_4":      0xdb,
+	"OEM_5":      0xdc,
+	"OEM_6":      0xdd,
+	"OEM_7":      0xde,
+}
+
+func init() {
+	for i := 0; i <= 9; i++ {
+		virtualKeys["NUMPAD"+strconv.Itoa(i)] = uint16(0x60 + i)
+	}
+	for i := 1; i <= 24; i++ {
+		virtualKeys["F"+strconv.Itoa(i)] = uint16(0x70 + i - 1)
+	}
+}
+
+// ACCELTABLEENTRY
+type rawEntry struct {
+	Flags   uint16
+	Key     uint16
+	ID      uint16
+	Padding uint16
+}
+
+// Entry is an accelerator table entry. Key is a virtual-key code if
+// Flags has VirtKeyFlag, and a character code otherwise.
+type Entry struct {
+	Flags uint16
+	Key   uint16
+	ID    uint16
+}
+
+// NewEntry returns an entry that sends command id on key with
+// modifiers, which are Ctrl(or Control), Shift, Alt, VirtKey and
+// NoInvert. Key is a virtual-key name like "F5" or "VK_DELETE", or
+// a single character.
+//
+// An entry is a virtual-key entry if key is a virtual-key name or
+// modifiers have Ctrl, Shift or VirtKey. A single character key of
+// such an entry must be a letter or a digit. Otherwise, the entry
+// matches the character as is, case-sensitively.
+func NewEntry(key string, modifiers []string, id uint16) (*Entry, error) {
+	e := &Entry{ID: id}
+	for _, m := range modifiers {
+		flag, ok := modifierFlags[strings.ToLower(m)]
+		if !ok {
+			return nil, errors.Errorf("unknown modifier: %q", m)
+		}
+		e.Flags |= flag
+	}
+	if e.Flags&(ShiftFlag|ControlFlag) != 0 {
+		e.Flags |= VirtKeyFlag
+	}
+
+	if vk, ok := virtualKeys[strings.TrimPrefix(strings.ToUpper(key), "VK_")]; ok && len([]rune(key)) > 1 {
+		e.Flags |= VirtKeyFlag
+		e.Key = vk
+		return e, nil
+	}
+	r := []rune(key)
+	if len(r) != 1 {
+		return nil, errors.Errorf("unknown key: %q", key)
+	}
+	c := r[0]
+	if e.Flags&VirtKeyFlag == 0 {
+		if c > 0xffff {
+			return nil, errors.Errorf("character %q is out of range", c)
+		}
+		e.Key = uint16(c)
+		return e, nil
+	}
+	switch {
+	case c >= 'a' && c <= 'z':
+		e.Key = uint16(c - 'a' + 'A')
+	case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		e.Key = uint16(c)
+	default:
+		return nil, errors.Errorf("character %q cannot be a virtual key; use a virtual-key name instead", c)
+	}
+	return e, nil
+}
+
+// Table is an accelerator table.
+type Table struct {
+	Entries []*Entry
+}
+
+// WriteTo writes the table to w as an array of ACCELTABLEENTRY.
+func (t *Table) WriteTo(w io.Writer) (int64, error) {
+	buf := new(bytes.Buffer)
+	for i, e := range t.Entries {
+		flags := e.Flags
+		if i == len(t.Entries)-1 {
+			flags |= lastFlag
+		}
+		common.BinaryWriteTo(buf, &rawEntry{
+			Flags: flags,
+			Key:   e.Key,
+			ID:    e.ID,
+		})
+	}
+	return buf.WriteTo(w)
+}
@@ -0,0 +1,65 @@
+package accel
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestNewEntry(t *testing.T) {
+	for _, tc := range []struct {
+		Key        string
+		Modifiers  []string
+		Flags      uint16
+		Code       uint16
+		ShouldFail bool
+	}{
+		{Key: "o", Modifiers: []string{"Ctrl"}, Flags: VirtKeyFlag | ControlFlag, Code: 'O'},
+		{Key: "S", Modifiers: []string{"control", "SHIFT"}, Flags: VirtKeyFlag | ControlFlag | ShiftFlag, Code: 'S'},
+		{Key: "F5", Flags: VirtKeyFlag, Code: 0x74},
+		{Key: "VK_DELETE", Modifiers: []string{"Alt"}, Flags: VirtKeyFlag | AltFlag, Code: 0x2e},
+		{Key: "numpad7", Flags: VirtKeyFlag, Code: 0x67},
+		{Key: "a", Modifiers: []string{"Alt"}, Flags: AltFlag, Code: 'a'},
+		{Key: "?", Code: '?'},
+		{Key: "?", Modifiers: []string{"Ctrl"}, ShouldFail: true},
+		{Key: "F25", ShouldFail: true},
+		{Key: "", ShouldFail: true},
+		{Key: "a", Modifiers: []string{"Super"}, ShouldFail: true},
+	} {
+		e, err := NewEntry(tc.Key, tc.Modifiers, 1)
+		if tc.ShouldFail {
+			if err == nil {
+				t.Fatalf("expected failure for %q%v, got no error", tc.Key, tc.Modifiers)
+			}
+			continue
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if e.Flags != tc.Flags || e.Key != tc.Code {
+			t.Fatalf("wrong entry for %q%v; expected (%#x, %#x), got (%#x, %#x)", tc.Key, tc.Modifiers, tc.Flags, tc.Code, e.Flags, e.Key)
+		}
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	tbl := &Table{
+		Entries: []*Entry{
+			{Flags: VirtKeyFlag | ControlFlag, Key: 'O', ID: 100},
+			{Flags: VirtKeyFlag, Key: 0x74, ID: 101},
+		},
+	}
+	b := new(bytes.Buffer)
+	n, err := tbl.WriteTo(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	if n != 16 || len(data) != 16 {
+		t.Fatalf("wrong data length; expected 16, got %d", len(data))
+	}
+	for i, v := range []uint16{0x09, 'O', 100, 0, 0x81, 0x74, 101, 0} {
+		if v2 := binary.LittleEndian.Uint16(data[i*2:]); v2 != v {
+			t.Fatalf("wrong word #%d; expected %#x, got %#x", i, v, v2)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Package ani provides animated cursor(ANI) file related functionalities.
+package ani
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+)
+
+// flags in ANIHEADER
+const (
+	iconFlag     = 0x1 // AF_ICON
+	sequenceFlag = 0x2 // AF_SEQUENCE
+)
+
+// ANIHEADER
+type header struct {
+	Size     uint32
+	Frames   uint32
+	Steps    uint32
+	Width    uint32
+	Height   uint32
+	BitCount uint32
+	Planes   uint32
+	Rate     uint32
+	Flags    uint32
+}
+
+type chunkHeader struct {
+	ID   [4]byte
+	Size uint32
+}
+
+// Cursor represents an animated cursor, which can be embedded as is as
+// an animated cursor or an animated icon resource.
+type Cursor struct {
+	Frames int
+	Steps  int
+	data   []byte
+	offset int64
+}
+
+// Read copies the cursor's file data to p.
+func (c *Cursor) Read(p []byte) (int, error) {
+	n := copy(p[:], c.data[c.offset:])
+	c.offset += int64(n)
+	return n, nil
+}
+
+// Size returns the cursor's file size.
+func (c *Cursor) Size() int64 {
+	return int64(len(c.data))
+}
+
+// Decode reads an ANI file from r and validates its structure.
+func Decode(r io.Reader) (*Cursor, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read data")
+	}
+
+	var riff chunkHeader
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &riff); err != nil {
+		return nil, errors.Wrap(err, "failed to read RIFF header")
+	}
+	if string(riff.ID[:]) != "RIFF" || len(data) < 12 || string(data[8:12]) != "ACON" {
+		return nil, errors.New("bad ANI file")
+	}
+	if riff.Size < 4 {
+		return nil, errors.Errorf("invalid RIFF chunk size: %d", riff.Size)
+	} else if int64(riff.Size)+8 > int64(len(data)) {
+		return nil, errors.New("RIFF chunk is truncated")
+	}
+
+	var h *header
+	var frames, rates, seqs int
+	if err := walkChunks(data[12:riff.Size+8], func(id string, b []byte) error {
+		switch id {
+		case "anih":
+			if h != nil {
+				return errors.New("duplicate anih chunk")
+			}
+			h = &header{}
+			if err := binary.Read(bytes.NewReader(b), binary.LittleEndian, h); err != nil {
+				return errors.Wrap(err, "failed to read anih chunk")
+			}
+			if h.Size != uint32(binary.Size(h)) {
+				return errors.Errorf("invalid anih chunk size: %d", h.Size)
+			}
+		case "rate":
+			rates = len(b) / 4
+		case "seq ":
+			seqs = len(b) / 4
+		case "LIST":
+			if len(b) < 4 || string(b[:4]) != "fram" {
+				return nil
+			}
+			return walkChunks(b[4:], func(id string, b []byte) error {
+				if id != "icon" {
+					return errors.Errorf("unexpected chunk %q in frame list", id)
+				}
+				frames++
+				return nil
+			})
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if h == nil {
+		return nil, errors.New("anih chunk not found")
+	} else if h.Frames == 0 || h.Steps == 0 {
+		return nil, errors.New("animation has no frames or steps")
+	} else if h.Flags&iconFlag == 0 {
+		return nil, errors.New("raw frame data is not supported; frames must be icons or cursors")
+	} else if frames != int(h.Frames) {
+		return nil, errors.Errorf("frame count mismatch; header says %d, got %d", h.Frames, frames)
+	} else if rates != 0 && rates != int(h.Steps) {
+		return nil, errors.Errorf("rate count mismatch; header says %d steps, got %d", h.Steps, rates)
+	} else if h.Flags&sequenceFlag != 0 && seqs != int(h.Steps) {
+		return nil, errors.Errorf("sequence count mismatch; header says %d steps, got %d", h.Steps, seqs)
+	}
+
+	return &Cursor{
+		Frames: int(h.Frames),
+		Steps:  int(h.Steps),
+		data:   data,
+	}, nil
+}
+
+// walkChunks calls cb for each RIFF chunk in b.
+func walkChunks(b []byte, cb func(id string, data []byte) error) error {
+	for len(b) > 0 {
+		var ch chunkHeader
+		if err := binary.Read(bytes.NewReader(b), binary.LittleEndian, &ch); err != nil {
+			return errors.Wrap(err, "failed to read chunk header")
+		}
+		b = b[binary.Size(&ch):]
+		if int64(ch.Size) > int64(len(b)) {
+			return errors.Errorf("chunk %q is truncated", ch.ID[:])
+		}
+		if err := cb(string(ch.ID[:]), b[:ch.Size]); err != nil {
+			return err
+		}
+		size := int(ch.Size) + int(ch.Size%2) // chunks are word aligned
+		if size > len(b) {
+			size = len(b)
+		}
+		b = b[size:]
+	}
+	return nil
+}
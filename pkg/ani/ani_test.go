@@ -0,0 +1,52 @@
+package ani
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	f, err := os.Open(filepath.Join("..", "..", "testdata", "cursor.ani"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	c, err := Decode(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Frames != 2 || c.Steps != 2 {
+		t.Fatalf("wrong frames and steps; expected (2, 2), got (%d, %d)", c.Frames, c.Steps)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Size() != fi.Size() {
+		t.Fatalf("wrong size; expected %d, got %d", fi.Size(), c.Size())
+	}
+}
+
+func TestDecode_invalidData(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "testdata", "cursor.ani"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range [][]byte{
+		{},
+		[]byte("RIFF\x04\x00\x00\x00ACOX"),
+		[]byte("RIFF\x04\x00\x00\x00ACON"), // no anih chunk
+		[]byte("RIFF\xff\x00\x00\x00ACON"), // truncated
+		[]byte("RIFF\x00\x00\x00\x00ACON"), // size doesn't cover form type
+		data[:len(data)-10],                // truncated frame
+		bytes.Replace(data, []byte("icon"), []byte("abcd"), 1), // bad frame chunk
+	} {
+		if _, err := Decode(bytes.NewReader(tc)); err == nil {
+			t.Fatalf("expected failure for case #%d, got no error", i)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package bmp provides bitmap resource related functionalities.
+package bmp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/pkg/errors"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// compression methods in BITMAPINFOHEADER
+const (
+	rgbCompression            = 0 // BI_RGB
+	bitfieldsCompression      = 3 // BI_BITFIELDS
+	alphaBitfieldsCompression = 6 // BI_ALPHABITFIELDS
+)
+
+// maxDimension limits width and height of a bitmap to keep its pixel
+// data size in range.
+const maxDimension = 0xffff
+
+// BITMAPFILEHEADER
+type fileHeader struct {
+	Type      [2]byte
+	Size      uint32
+	Reserved1 uint16
+	Reserved2 uint16
+	OffBits   uint32
+}
+
+// BITMAPCOREHEADER
+type coreHeader struct {
+	Size     uint32
+	Width    uint16
+	Height   uint16
+	Planes   uint16
+	BitCount uint16
+}
+
+// BITMAPINFOHEADER
+type infoHeader struct {
+	Size          uint32
+	Width         int32
+	Height        int32
+	Planes        uint16
+	BitCount      uint16
+	Compression   uint32
+	SizeImage     uint32
+	XPelsPerMeter int32
+	YPelsPerMeter int32
+	ClrUsed       uint32
+	ClrImportant  uint32
+}
+
+// Bitmap represents a packed device-independent bitmap, which is a
+// bitmap file without BITMAPFILEHEADER, as bitmap resources are stored.
+type Bitmap struct {
+	Width    int
+	Height   int
+	BitCount int
+	data     []byte
+	offset   int64
+}
+
+// Read copies the bitmap's data to p.
+func (b *Bitmap) Read(p []byte) (int, error) {
+	n := copy(p[:], b.data[b.offset:])
+	b.offset += int64(n)
+	return n, nil
+}
+
+// Size returns the bitmap's data size.
+func (b *Bitmap) Size() int64 {
+	return int64(len(b.data))
+}
+
+// Bytes returns the bitmap's data regardless of read offset.
+func (b *Bitmap) Bytes() []byte {
+	return b.data
+}
+
+// Decode reads a BMP file or a PNG image from r and returns it as
+// a packed bitmap. PNG images are converted to 32-bit bitmaps.
+func Decode(r io.Reader) (*Bitmap, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read data")
+	}
+	if bytes.HasPrefix(data, pngSignature) {
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to decode PNG image")
+		}
+		return FromImage(img)
+	} else if !bytes.HasPrefix(data, []byte("BM")) {
+		return nil, errors.New("data is neither a BMP file nor a PNG image")
+	}
+	return decodeFile(data)
+}
+
+func decodeFile(data []byte) (*Bitmap, error) {
+	var fh fileHeader
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &fh); err != nil {
+		return nil, errors.Wrap(err, "failed to read file header")
+	}
+	dib := data[binary.Size(&fh):]
+	if len(dib) < 4 {
+		return nil, errors.New("bitmap header is truncated")
+	}
+
+	var width, height, bitCount, colors, colorSize, masks int
+	var compression, sizeImage uint32
+	switch size := binary.LittleEndian.Uint32(dib); size {
+	case 12:
+		var h coreHeader
+		if err := binary.Read(bytes.NewReader(dib), binary.LittleEndian, &h); err != nil {
+			return nil, errors.Wrap(err, "failed to read bitmap header")
+		}
+		width, height, bitCount = int(h.Width), int(h.Height), int(h.BitCount)
+		if h.Planes != 1 {
+			return nil, errors.Errorf("invalid bitmap planes: %d", h.Planes)
+		}
+		colorSize = 3
+	case 40, 52, 56, 108, 124:
+		var h infoHeader
+		if err := binary.Read(bytes.NewReader(dib), binary.LittleEndian, &h); err != nil {
+			return nil, errors.Wrap(err, "failed to read bitmap header")
+		}
+		width, height, bitCount = int(h.Width), int(h.Height), int(h.BitCount)
+		if h.Planes != 1 {
+			return nil, errors.Errorf("invalid bitmap planes: %d", h.Planes)
+		}
+		colors, colorSize = int(h.ClrUsed), 4
+		compression, sizeImage = h.Compression, h.SizeImage
+		if size == 40 { // masks follow the header only for BITMAPINFOHEADER
+			switch compression {
+			case bitfieldsCompression:
+				masks = 12
+			case alphaBitfieldsCompression:
+				masks = 16
+			}
+		}
+	default:
+		return nil, errors.Errorf("invalid bitmap header size: %d", size)
+	}
+
+	switch bitCount {
+	case 0, 1, 4, 8, 16, 24, 32: // 0 is for JPEG and PNG compressions
+	default:
+		return nil, errors.Errorf("invalid bitmap bit count: %d", bitCount)
+	}
+	if height < 0 {
+		height = -height // top-down
+	}
+	if width <= 0 || height == 0 || width > maxDimension || height > maxDimension {
+		return nil, errors.Errorf("invalid bitmap size: %dx%d", width, height)
+	}
+	if colors == 0 && bitCount >= 1 && bitCount <= 8 {
+		colors = 1 << uint(bitCount)
+	}
+
+	// A packed bitmap's pixels must immediately follow its color table.
+	// Remove the gap between them if there's one.
+	headerSize := int(binary.LittleEndian.Uint32(dib)) + masks + colors*colorSize
+	bits := int(fh.OffBits) - binary.Size(&fh)
+	if bits < headerSize || bits > len(dib) {
+		return nil, errors.Errorf("invalid bitmap data offset: %d", fh.OffBits)
+	}
+	pixels := dib[bits:]
+	if compression == rgbCompression || compression == bitfieldsCompression || compression == alphaBitfieldsCompression {
+		stride := (uint64(width)*uint64(bitCount) + 31) / 32 * 4
+		n := stride * uint64(height)
+		if uint64(len(pixels)) < n {
+			return nil, errors.Errorf("bitmap data is truncated; expected %d bytes, got %d", n, len(pixels))
+		}
+		pixels = pixels[:n]
+	} else if sizeImage != 0 {
+		if len(pixels) < int(sizeImage) {
+			return nil, errors.Errorf("bitmap data is truncated; expected %d bytes, got %d", sizeImage, len(pixels))
+		}
+		pixels = pixels[:sizeImage]
+	}
+
+	packed := make([]byte, 0, headerSize+len(pixels))
+	packed = append(packed, dib[:headerSize]...)
+	packed = append(packed, pixels...)
+	return &Bitmap{
+		Width:    width,
+		Height:   height,
+		BitCount: bitCount,
+		data:     packed,
+	}, nil
+}
+
+// FromImage converts img to a 32-bit bitmap with alpha channel.
+func FromImage(img image.Image) (*Bitmap, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w < 1 || h < 1 {
+		return nil, errors.Errorf("invalid image size: %dx%d", w, h)
+	}
+	buf := new(bytes.Buffer)
+	if _, err := common.BinaryWriteTo(buf, &infoHeader{
+		Size:      uint32(binary.Size(&infoHeader{})),
+		Width:     int32(w),
+		Height:    int32(h),
+		Planes:    1,
+		BitCount:  32,
+		SizeImage: uint32(w * h * 4),
+	}); err != nil {
+		return nil, err
+	}
+	for y := h - 1; y >= 0; y-- { // bottom-up
+		for x := 0; x < w; x++ {
+			c := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			buf.Write([]byte{c.B, c.G, c.R, c.A})
+		}
+	}
+	return &Bitmap{
+		Width:    w,
+		Height:   h,
+		BitCount: 32,
+		data:     buf.Bytes(),
+	}, nil
+}
@@ -0,0 +1,76 @@
+package bmp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	for _, tc := range []struct {
+		Name     string
+		Width    int
+		Height   int
+		BitCount int
+		Size     int64
+	}{
+		{"bitmap.bmp", 3, 2, 24, 40 + 12*2}, // gap between header and pixels is removed
+		{"icon16.png", 16, 16, 32, 40 + 16*16*4},
+	} {
+		f, err := os.Open(filepath.Join("..", "..", "testdata", tc.Name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Width != tc.Width || b.Height != tc.Height || b.BitCount != tc.BitCount {
+			t.Fatalf("wrong bitmap info for %s; expected %dx%d %d bpp, got %dx%d %d bpp", tc.Name, tc.Width, tc.Height, tc.BitCount, b.Width, b.Height, b.BitCount)
+		}
+		if b.Size() != tc.Size {
+			t.Fatalf("wrong size for %s; expected %d, got %d", tc.Name, tc.Size, b.Size())
+		}
+		if size := binary.LittleEndian.Uint32(b.Bytes()); size != 40 {
+			t.Fatalf("wrong header size for %s; expected 40, got %d", tc.Name, size)
+		}
+	}
+}
+
+func TestDecode_invalidData(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "testdata", "bitmap.bmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withOffset := func(off byte) []byte {
+		b := append([]byte{}, data...)
+		b[10] = off
+		return b
+	}
+	withSize := func(width, height uint32) []byte {
+		b := append([]byte{}, data...)
+		binary.LittleEndian.PutUint32(b[18:], width)
+		binary.LittleEndian.PutUint32(b[22:], height)
+		return b
+	}
+	for i, tc := range [][]byte{
+		{},
+		[]byte("BX"),
+		[]byte("BM\x00\x00"),
+		data[:len(data)-1],  // truncated pixels
+		withOffset(14 + 39), // overlaps header
+		withOffset(200),     // out of range
+		bytes.Replace(data, []byte{24, 0}, []byte{7, 0}, 1), // bad bit count
+		withSize(0x7fffffff, 0x7fffffff),                    // too large
+		withSize(0x10000, 1),                                // too wide
+		withSize(0xffff, 0xffff),                            // truncated pixels of the largest size
+	} {
+		if _, err := Decode(bytes.NewReader(tc)); err == nil {
+			t.Fatalf("expected failure for case #%d, got no error", i)
+		}
+	}
+}
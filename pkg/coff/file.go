@@ -29,20 +29,31 @@ type rawFileHeader struct {
 
 // File is a COFF file.
 type File struct {
+	machine         Machine
+	header          *rawFileHeader // file header read by Parse, nil for a new file
 	sections        []*section
+	symbols         []*Symbol
+	symbolRecords   []rawSymbol // symbol table read by Parse, with auxiliary records
+	newSymbols      []*section  // sections that need a section symbol appended
 	symbolsOffset   uint32
 	strings         []*_string
 	stringTable     map[string]*_string
 	stringTableSize uint32
 }
 
-// New returns newly created COFF file.
-func New() *File {
+// New returns newly created COFF file targeting machine m.
+func New(m Machine) *File {
 	return &File{
+		machine:     m,
 		stringTable: make(map[string]*_string),
 	}
 }
 
+// Machine returns the file's target machine type.
+func (f *File) Machine() Machine {
+	return f.machine
+}
+
 // AddSection adds section s to file.
 func (f *File) AddSection(s Section) error {
 	for _, sec := range f.sections {
@@ -51,7 +62,9 @@ func (f *File) AddSection(s Section) error {
 		}
 	}
 	f.sections = append(f.sections, &section{
-		Section: s,
+		Section:         s,
+		number:          uint16(len(f.sections)) + 1,
+		characteristics: 0x40000040, // IMAGE_SCN_MEM_READ|IMAGE_SCN_CNT_INITIALIZED_DATA
 	})
 	if len(s.Name()) > 8 {
 		if _, ok := f.stringTable[s.Name()]; !ok {
@@ -86,29 +99,72 @@ func (f *File) freeze() {
 		s.relocationsOffset = offset
 		offset += uint32(binary.Size(&rawRelocation{}) * len(s.Relocations()))
 	}
+	// sections keep the section symbols read by Parse, and others get a
+	// new one after the read symbols
+	f.newSymbols = nil
+	for i, s := range f.sections {
+		if index, ok := f.findSectionSymbol(i); ok {
+			s.symbolIndex = index
+			continue
+		}
+		s.symbolIndex = uint32(len(f.symbolRecords) + len(f.newSymbols))
+		f.newSymbols = append(f.newSymbols, s)
+	}
 	f.symbolsOffset = offset
-	offset += uint32(binary.Size(&rawSymbol{}) * len(f.sections))
-	offset += 4  // string table size
+	offset += uint32(binary.Size(&rawSymbol{}) * (len(f.symbolRecords) + len(f.newSymbols)))
 	so := offset // start offset of string table
+	offset += 4  // string table size
 	for _, s := range f.strings {
-		s.offset = offset
+		s.offset = offset - so // offset is relative to the string table
 		offset += uint32(len(s.b))
 	}
-	f.stringTableSize = offset - so + 4
+	f.stringTableSize = offset - so
 }
 
-// WriteTo writes COFF file data to w.
+// findSectionSymbol returns the index of a static symbol at the start
+// of i-th section in the symbol table read by Parse.
+func (f *File) findSectionSymbol(i int) (uint32, bool) {
+	for j := 0; j < len(f.symbolRecords); j++ {
+		rs := f.symbolRecords[j]
+		if rs.SectionNumber == uint16(i)+1 && rs.Value == 0 && rs.StorageClass == 3 { // IMAGE_SYM_CLASS_STATIC
+			return uint32(j), true
+		}
+		j += int(rs.NumberOfAuxSymbols)
+	}
+	return 0, false
+}
+
+// WriteTo writes COFF file data to w. A file read by Parse keeps its
+// file header, section characteristics, relocations and symbol table.
 func (f *File) WriteTo(w io.Writer) (int64, error) {
 	var written int64
 
+	var timeDateStamp uint32
+	var characteristics uint16
+	if f.header != nil {
+		timeDateStamp = f.header.TimeDateStamp
+		characteristics = f.header.Characteristics
+	} else {
+		var err error
+		characteristics, err = f.machine.characteristics()
+		if err != nil {
+			return written, err
+		}
+	}
+	relocationType, err := f.machine.addr32NBRelocationType()
+	if err != nil {
+		return written, err
+	}
+
 	f.freeze()
 
 	n, err := common.BinaryWriteTo(w, &rawFileHeader{
-		Machine:              0x14c, // IMAGE_FILE_MACHINE_I386
+		Machine:              uint16(f.machine),
 		NumberOfSections:     uint16(len(f.sections)),
+		TimeDateStamp:        timeDateStamp,
 		PointerToSymbolTable: f.symbolsOffset,
-		NumberOfSymbols:      uint32(len(f.sections)),
-		Characteristics:      0x0100, // IMAGE_FILE_32BIT_MACHINE
+		NumberOfSymbols:      uint32(len(f.symbolRecords) + len(f.newSymbols)),
+		Characteristics:      characteristics,
 	})
 	if err != nil {
 		return written, err
@@ -128,7 +184,7 @@ func (f *File) WriteTo(w io.Writer) (int64, error) {
 			PointerToRawData:     s.dataOffset,
 			PointerToRelocations: s.relocationsOffset,
 			NumberOfRelocations:  uint16(len(s.Relocations())),
-			Characteristics:      0x40000040, // IMAGE_SCN_MEM_READ|IMAGE_SCN_CNT_INITIALIZED_DATA
+			Characteristics:      s.characteristics,
 		})
 		if err != nil {
 			return written, err
@@ -144,13 +200,18 @@ func (f *File) WriteTo(w io.Writer) (int64, error) {
 		written += n
 	}
 
-	for i, s := range f.sections {
+	for _, s := range f.sections {
 		for _, r := range s.Relocations() {
-			n, err := common.BinaryWriteTo(w, &rawRelocation{
+			rr := &rawRelocation{
 				VirtualAddress:   r.VirtualAddress(),
-				SymbolTableIndex: uint32(i),
-				Type:             0x0007, // IMAGE_REL_I386_DIR32NB
-			})
+				SymbolTableIndex: s.symbolIndex,
+				Type:             relocationType,
+			}
+			if r, ok := r.(*RawRelocation); ok {
+				rr.SymbolTableIndex = r.SymbolTableIndex
+				rr.Type = r.Type
+			}
+			n, err := common.BinaryWriteTo(w, rr)
 			if err != nil {
 				return written, err
 			}
@@ -158,7 +219,12 @@ func (f *File) WriteTo(w io.Writer) (int64, error) {
 		}
 	}
 
-	for i, s := range f.sections {
+	n, err = common.BinaryWriteTo(w, f.symbolRecords)
+	if err != nil {
+		return written, err
+	}
+	written += n
+	for _, s := range f.newSymbols {
 		var name [8]byte
 		if len(s.Name()) > 8 {
 			binary.LittleEndian.PutUint32(name[4:], f.stringTable[s.Name()].offset)
@@ -167,7 +233,7 @@ func (f *File) WriteTo(w io.Writer) (int64, error) {
 		}
 		n, err := common.BinaryWriteTo(w, &rawSymbol{
 			Name:          name,
-			SectionNumber: uint16(i) + 1,
+			SectionNumber: s.number,
 			StorageClass:  3, // IMAGE_SYM_CLASS_STATIC
 		})
 		if err != nil {
@@ -0,0 +1,296 @@
+package coff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+type dummySection struct {
+	name string
+	data []byte
+}
+
+func (s *dummySection) Name() string {
+	return s.name
+}
+
+func (s *dummySection) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(s.data)
+	return int64(n), err
+}
+
+func (s *dummySection) Size() int {
+	return len(s.data)
+}
+
+func (s *dummySection) Relocations() []Relocation {
+	return []Relocation{dummyRelocation(0)}
+}
+
+type dummyRelocation uint32
+
+func (r dummyRelocation) VirtualAddress() uint32 {
+	return uint32(r)
+}
+
+func TestWriteTo_machine(t *testing.T) {
+	for _, tc := range []struct {
+		Machine         Machine
+		Characteristics uint16
+		RelocationType  uint16
+	}{
+		{MachineI386, 0x0100, 0x0007},
+		{MachineAMD64, 0, 0x0003},
+		{MachineARM, 0x0100, 0x0002},
+		{MachineARM64, 0, 0x0002},
+	} {
+		f := New(tc.Machine)
+		if err := f.AddSection(&dummySection{".data", []byte{1, 2, 3, 4}}); err != nil {
+			t.Fatal(err)
+		}
+		b := new(bytes.Buffer)
+		if _, err := f.WriteTo(b); err != nil {
+			t.Fatal(err)
+		}
+		r := bytes.NewReader(b.Bytes())
+		var fh rawFileHeader
+		if err := binary.Read(r, binary.LittleEndian, &fh); err != nil {
+			t.Fatal(err)
+		}
+		if fh.Machine != uint16(tc.Machine) {
+			t.Fatalf("wrong machine; expected %#04x, got %#04x", tc.Machine, fh.Machine)
+		}
+		if fh.Characteristics != tc.Characteristics {
+			t.Fatalf("wrong characteristics; expected %#04x, got %#04x", tc.Characteristics, fh.Characteristics)
+		}
+		var sh rawSectionHeader
+		if err := binary.Read(r, binary.LittleEndian, &sh); err != nil {
+			t.Fatal(err)
+		}
+		var rel rawRelocation
+		if err := binary.Read(io.NewSectionReader(r, int64(sh.PointerToRelocations), 10), binary.LittleEndian, &rel); err != nil {
+			t.Fatal(err)
+		}
+		if rel.Type != tc.RelocationType {
+			t.Fatalf("wrong relocation type; expected %#04x, got %#04x", tc.RelocationType, rel.Type)
+		}
+	}
+}
+
+func TestWriteTo_longName(t *testing.T) {
+	f := New(MachineAMD64)
+	if err := f.AddSection(&dummySection{".verylongname", []byte{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	b := new(bytes.Buffer)
+	if _, err := f.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(b.Bytes())
+	var fh rawFileHeader
+	if err := binary.Read(r, binary.LittleEndian, &fh); err != nil {
+		t.Fatal(err)
+	}
+	var sh rawSectionHeader
+	if err := binary.Read(r, binary.LittleEndian, &sh); err != nil {
+		t.Fatal(err)
+	}
+	// long name offset is relative to the string table, whose first
+	// four bytes hold the table size
+	if name := string(bytes.TrimRight(sh.Name[:], "\x00")); name != "/4" {
+		t.Fatalf("wrong section name; expected /4, got %q", name)
+	}
+	strtab := b.Bytes()[int(fh.PointerToSymbolTable)+int(fh.NumberOfSymbols)*binary.Size(&rawSymbol{}):]
+	if size := binary.LittleEndian.Uint32(strtab); int(size) != len(strtab) {
+		t.Fatalf("wrong string table size; expected %d, got %d", len(strtab), size)
+	}
+	if name := string(bytes.TrimRight(strtab[4:], "\x00")); name != ".verylongname" {
+		t.Fatalf("wrong string at offset 4; expected .verylongname, got %q", name)
+	}
+}
+
+func TestWriteTo_invalidMachine(t *testing.T) {
+	f := New(Machine(0x1234))
+	if _, err := f.WriteTo(new(bytes.Buffer)); err == nil {
+		t.Fatal("expected failure, got no error")
+	}
+}
+
+func TestMachineFromGOARCH(t *testing.T) {
+	for _, arch := range []string{"386", "amd64", "arm", "arm64"} {
+		m, err := MachineFromGOARCH(arch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.GOARCH() != arch {
+			t.Fatalf("wrong GOARCH; expected %q, got %q", arch, m.GOARCH())
+		}
+	}
+	if _, err := MachineFromGOARCH("mips"); err == nil {
+		t.Fatal("expected failure, got no error")
+	}
+}
+
+func TestParse(t *testing.T) {
+	f := New(MachineAMD64)
+	for _, s := range []*dummySection{
+		{".data", []byte{1, 2, 3, 4}},
+		{".verylongname", []byte{5, 6}},
+	} {
+		if err := f.AddSection(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b := new(bytes.Buffer)
+	if _, err := f.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+
+	f2, err := Parse(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f2.Machine() != MachineAMD64 {
+		t.Fatalf("wrong machine; expected %#04x, got %#04x", MachineAMD64, f2.Machine())
+	}
+	for _, tc := range []struct {
+		Name string
+		Data []byte
+	}{
+		{".data", []byte{1, 2, 3, 4}},
+		{".verylongname", []byte{5, 6}},
+	} {
+		s, err := f2.Section(tc.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rs, ok := s.(*RawSection)
+		if !ok {
+			t.Fatalf("wrong section type; expected *RawSection, got %T", s)
+		}
+		if !bytes.Equal(rs.Data(), tc.Data) {
+			t.Fatalf("wrong section data; expected %+q, got %+q", tc.Data, rs.Data())
+		}
+		if len(rs.Relocations()) != 1 {
+			t.Fatalf("wrong relocations length; expected 1, got %d", len(rs.Relocations()))
+		}
+	}
+	syms := f2.Symbols()
+	if len(syms) != 2 {
+		t.Fatalf("wrong symbols length; expected 2, got %d", len(syms))
+	}
+	if syms[1].Name != ".verylongname" || syms[1].SectionNumber != 2 {
+		t.Fatalf("wrong symbol; got %+v", syms[1])
+	}
+
+	b2 := new(bytes.Buffer)
+	if _, err := f2.WriteTo(b2); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), b2.Bytes()) {
+		t.Fatal("rewritten file differs from original")
+	}
+}
+
+func TestParse_invalidData(t *testing.T) {
+	f := New(MachineAMD64)
+	if err := f.AddSection(&dummySection{".verylongname", []byte{1, 2}}); err != nil {
+		t.Fatal(err)
+	}
+	b := new(bytes.Buffer)
+	if _, err := f.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	strtab := len(data) - len(".verylongname\x00") - 4
+	withStringTableSize := func(size uint32) []byte {
+		b := append([]byte{}, data...)
+		binary.LittleEndian.PutUint32(b[strtab:], size)
+		return b
+	}
+
+	for i, tc := range [][]byte{
+		{},
+		{0x64, 0x86, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		data[:len(data)-1],              // truncated string table
+		withStringTableSize(0xffffffff), // string table size out of range
+		withStringTableSize(2),          // string table size too small
+	} {
+		if _, err := Parse(bytes.NewReader(tc)); err == nil {
+			t.Fatalf("expected failure for case #%d, got no error", i)
+		}
+	}
+}
+
+func TestParse_rewrite(t *testing.T) {
+	// an object with a code section, a relocation to an external symbol
+	// and auxiliary symbol records, laid out as WriteTo does
+	b := new(bytes.Buffer)
+	for _, v := range []interface{}{
+		&rawFileHeader{Machine: uint16(MachineAMD64), NumberOfSections: 2, TimeDateStamp: 0x12345678, PointerToSymbolTable: 122, NumberOfSymbols: 5, Characteristics: 0x0004},
+		&rawSectionHeader{Name: [8]byte{'.', 't', 'e', 'x', 't'}, SizeOfRawData: 8, PointerToRawData: 100, PointerToRelocations: 112, NumberOfRelocations: 1, Characteristics: 0x60500020},
+		&rawSectionHeader{Name: [8]byte{'/', '4'}, SizeOfRawData: 4, PointerToRawData: 108, PointerToRelocations: 122, Characteristics: 0xc0300040},
+		[]byte{0xe8, 0, 0, 0, 0, 0xc3, 0x90, 0x90},
+		[]byte{1, 2, 3, 4},
+		&rawRelocation{VirtualAddress: 1, SymbolTableIndex: 3, Type: 0x0004}, // IMAGE_REL_AMD64_REL32
+		&rawSymbol{Name: [8]byte{'.', 't', 'e', 'x', 't'}, SectionNumber: 1, StorageClass: 3, NumberOfAuxSymbols: 1},
+		&rawSymbol{Name: [8]byte{8, 0, 0, 0, 1, 0}}, // section definition
+		&rawSymbol{Name: [8]byte{4: 4}, SectionNumber: 2, StorageClass: 3},
+		&rawSymbol{Name: [8]byte{'m', 'a', 'i', 'n'}, SectionNumber: 1, StorageClass: 2},
+		&rawSymbol{Name: [8]byte{4: 18}, StorageClass: 2},
+		uint32(4 + 14 + 15),
+		[]byte(".verylongdata\x00longsymbolname\x00"),
+	} {
+		if err := binary.Write(b, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := Parse(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if syms := f.Symbols(); len(syms) != 4 || syms[3].Name != "longsymbolname" {
+		t.Fatalf("wrong symbols; got %+v", syms)
+	}
+	b2 := new(bytes.Buffer)
+	if _, err := f.WriteTo(b2); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), b2.Bytes()) {
+		t.Fatalf("rewritten file differs from original:\n% x\n% x", b.Bytes(), b2.Bytes())
+	}
+
+	// a new section gets a section symbol after the read ones
+	if err := f.AddSection(&dummySection{".verylongname", []byte{5, 6}}); err != nil {
+		t.Fatal(err)
+	}
+	b3 := new(bytes.Buffer)
+	if _, err := f.WriteTo(b3); err != nil {
+		t.Fatal(err)
+	}
+	f2, err := Parse(bytes.NewReader(b3.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	syms := f2.Symbols()
+	if len(syms) != 5 || syms[4].Name != ".verylongname" || syms[4].SectionNumber != 3 {
+		t.Fatalf("wrong symbols; got %+v", syms)
+	}
+	s, err := f2.Section(".verylongname")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := s.Relocations()[0].(*RawRelocation); r.SymbolTableIndex != 5 || r.Type != 0x0003 {
+		t.Fatalf("wrong relocation; expected symbol 5 and type 0x0003, got %+v", r)
+	}
+	s, err = f2.Section(".text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := s.Relocations()[0].(*RawRelocation); r.SymbolTableIndex != 3 || r.Type != 0x0004 {
+		t.Fatalf("wrong relocation; expected symbol 3 and type 0x0004, got %+v", r)
+	}
+}
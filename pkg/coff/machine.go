@@ -0,0 +1,71 @@
+package coff
+
+import "github.com/pkg/errors"
+
+// Machine is a target machine type of COFF file.
+type Machine uint16
+
+// Supported target machine types.
+const (
+	MachineI386  Machine = 0x014c // IMAGE_FILE_MACHINE_I386
+	MachineAMD64 Machine = 0x8664 // IMAGE_FILE_MACHINE_AMD64
+	MachineARM   Machine = 0x01c4 // IMAGE_FILE_MACHINE_ARMNT
+	MachineARM64 Machine = 0xaa64 // IMAGE_FILE_MACHINE_ARM64
+)
+
+// MachineFromGOARCH returns target machine type for given GOARCH value.
+func MachineFromGOARCH(goarch string) (Machine, error) {
+	switch goarch {
+	case "386":
+		return MachineI386, nil
+	case "amd64":
+		return MachineAMD64, nil
+	case "arm":
+		return MachineARM, nil
+	case "arm64":
+		return MachineARM64, nil
+	}
+	return 0, errors.Errorf("unsupported architecture: %q", goarch)
+}
+
+// GOARCH returns GOARCH value that corresponds to m.
+func (m Machine) GOARCH() string {
+	switch m {
+	case MachineI386:
+		return "386"
+	case MachineAMD64:
+		return "amd64"
+	case MachineARM:
+		return "arm"
+	case MachineARM64:
+		return "arm64"
+	}
+	return ""
+}
+
+// characteristics returns file header characteristics for m.
+func (m Machine) characteristics() (uint16, error) {
+	switch m {
+	case MachineI386, MachineARM:
+		return 0x0100, nil // IMAGE_FILE_32BIT_MACHINE
+	case MachineAMD64, MachineARM64:
+		return 0, nil
+	}
+	return 0, errors.Errorf("unsupported machine type: %#04x", uint16(m))
+}
+
+// addr32NBRelocationType returns relocation type for 32-bit address
+// without an image base(RVA) for m.
+func (m Machine) addr32NBRelocationType() (uint16, error) {
+	switch m {
+	case MachineI386:
+		return 0x0007, nil // IMAGE_REL_I386_DIR32NB
+	case MachineAMD64:
+		return 0x0003, nil // IMAGE_REL_AMD64_ADDR32NB
+	case MachineARM:
+		return 0x0002, nil // IMAGE_REL_ARM_ADDR32NB
+	case MachineARM64:
+		return 0x0002, nil // IMAGE_REL_ARM64_ADDR32NB
+	}
+	return 0, errors.Errorf("unsupported machine type: %#04x", uint16(m))
+}
@@ -0,0 +1,224 @@
+package coff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// Open opens the named COFF object file and parses it.
+func Open(name string) (*File, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to open file")
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse reads a COFF object file from r. Sections that have a decoder
+// registered by RegisterSectionDecoder are decoded by it, and others
+// are returned as *RawSection. Importing package rsrc registers a
+// decoder for .rsrc section.
+func Parse(r io.ReaderAt) (*File, error) {
+	var fh rawFileHeader
+	if err := binary.Read(io.NewSectionReader(r, 0, int64(binary.Size(&fh))), binary.LittleEndian, &fh); err != nil {
+		return nil, errors.Wrap(err, "failed to read file header")
+	}
+	if fh.SizeOfOptionalHeader != 0 {
+		return nil, errors.New("optional header is not supported")
+	}
+
+	f := New(Machine(fh.Machine))
+	f.header = &fh
+
+	strtab, err := readStringTable(r, &fh)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read string table")
+	}
+	if len(strtab) > 4 {
+		// strings are kept at their offsets, and new ones are appended
+		f.strings = append(f.strings, &_string{b: strtab[4:]})
+	}
+
+	shOffset := int64(binary.Size(&fh))
+	shSize := int64(binary.Size(&rawSectionHeader{}))
+	for i := 0; i < int(fh.NumberOfSections); i++ {
+		var sh rawSectionHeader
+		if err := binary.Read(io.NewSectionReader(r, shOffset+int64(i)*shSize, shSize), binary.LittleEndian, &sh); err != nil {
+			return nil, errors.Wrapf(err, "failed to read section header #%d", i)
+		}
+		name, err := sectionName(sh.Name, strtab)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read section #%d's name", i)
+		} else if sh.NumberOfLineNumbers != 0 {
+			return nil, errors.Errorf("section %q has line numbers, which are not supported", name)
+		}
+		if offset, ok, _ := longNameOffset(sh.Name); ok {
+			// long names are kept at their offsets in the string table
+			f.stringTable[name] = &_string{offset: offset}
+		}
+		data, err := ioutil.ReadAll(io.NewSectionReader(r, int64(sh.PointerToRawData), int64(sh.SizeOfRawData)))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read section %q's data", name)
+		}
+		if len(data) != int(sh.SizeOfRawData) {
+			return nil, errors.Errorf("section %q's data is truncated", name)
+		}
+		relocs, err := readRelocations(r, &sh)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read section %q's relocations", name)
+		}
+		var s Section
+		if d, ok := sectionDecoders[name]; ok {
+			s, err = d(data, relocs)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to decode section %q", name)
+			}
+		} else {
+			s = NewRawSection(name, data, relocs)
+		}
+		if err := f.AddSection(s); err != nil {
+			return nil, errors.Wrapf(err, "failed to add section %q", name)
+		}
+		f.sections[i].characteristics = sh.Characteristics
+	}
+
+	f.symbolRecords, f.symbols, err = readSymbols(r, &fh, strtab)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read symbol table")
+	}
+
+	return f, nil
+}
+
+// Symbols returns symbols read by Parse, without auxiliary records. It
+// is nil for a new file, whose symbols are generated from sections when
+// the file is written.
+func (f *File) Symbols() []*Symbol {
+	return f.symbols
+}
+
+func readStringTable(r io.ReaderAt, fh *rawFileHeader) ([]byte, error) {
+	if fh.PointerToSymbolTable == 0 {
+		return nil, nil
+	}
+	offset := int64(fh.PointerToSymbolTable) + int64(fh.NumberOfSymbols)*int64(binary.Size(&rawSymbol{}))
+	var size uint32
+	if err := binary.Read(io.NewSectionReader(r, offset, 4), binary.LittleEndian, &size); err != nil {
+		if err == io.EOF {
+			return nil, nil // string table is optional
+		}
+		return nil, errors.Wrap(err, "failed to read string table size")
+	}
+	if size < 4 {
+		return nil, errors.Errorf("invalid string table size: %d", size)
+	}
+	// read through a section reader, so that the allocation is bounded
+	// by the actual file length rather than the size in the file
+	data, err := ioutil.ReadAll(io.NewSectionReader(r, offset+4, int64(size)-4))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read string table data")
+	}
+	if len(data) != int(size)-4 {
+		return nil, errors.Errorf("string table is truncated; expected %d bytes, got %d", size, len(data)+4)
+	}
+	return append(make([]byte, 4, 4+len(data)), data...), nil
+}
+
+func stringAt(strtab []byte, offset uint32) (string, error) {
+	if offset < 4 || int(offset) >= len(strtab) {
+		return "", errors.Errorf("invalid string table offset: %d", offset)
+	}
+	b := strtab[offset:]
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b), nil
+}
+
+func sectionName(name [8]byte, strtab []byte) (string, error) {
+	offset, ok, err := longNameOffset(name)
+	if err != nil {
+		return "", err
+	} else if ok {
+		return stringAt(strtab, offset)
+	}
+	return string(bytes.TrimRight(name[:], "\x00")), nil
+}
+
+// longNameOffset returns the string table offset of a section name in
+// "/offset" form.
+func longNameOffset(name [8]byte) (uint32, bool, error) {
+	if name[0] != '/' {
+		return 0, false, nil
+	}
+	s := string(bytes.TrimRight(name[1:], "\x00"))
+	offset, err := strconv.ParseUint(s, 10, 32)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "invalid long name offset: %q", s)
+	}
+	return uint32(offset), true, nil
+}
+
+func symbolName(name [8]byte, strtab []byte) (string, error) {
+	if binary.LittleEndian.Uint32(name[:4]) == 0 {
+		return stringAt(strtab, binary.LittleEndian.Uint32(name[4:]))
+	}
+	return string(bytes.TrimRight(name[:], "\x00")), nil
+}
+
+func readRelocations(r io.ReaderAt, sh *rawSectionHeader) ([]Relocation, error) {
+	size := int64(binary.Size(&rawRelocation{}))
+	var relocs []Relocation
+	for i := 0; i < int(sh.NumberOfRelocations); i++ {
+		var rr rawRelocation
+		if err := binary.Read(io.NewSectionReader(r, int64(sh.PointerToRelocations)+int64(i)*size, size), binary.LittleEndian, &rr); err != nil {
+			return nil, errors.Wrapf(err, "failed to read relocation #%d", i)
+		}
+		relocs = append(relocs, &RawRelocation{
+			VA:               rr.VirtualAddress,
+			SymbolTableIndex: rr.SymbolTableIndex,
+			Type:             rr.Type,
+		})
+	}
+	return relocs, nil
+}
+
+// readSymbols returns all records of the symbol table, and symbols in
+// them without auxiliary records.
+func readSymbols(r io.ReaderAt, fh *rawFileHeader, strtab []byte) ([]rawSymbol, []*Symbol, error) {
+	size := int64(binary.Size(&rawSymbol{}))
+	var records []rawSymbol
+	for i := 0; i < int(fh.NumberOfSymbols); i++ {
+		var rs rawSymbol
+		if err := binary.Read(io.NewSectionReader(r, int64(fh.PointerToSymbolTable)+int64(i)*size, size), binary.LittleEndian, &rs); err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to read symbol #%d", i)
+		}
+		records = append(records, rs)
+	}
+	var symbols []*Symbol
+	for i := 0; i < len(records); i++ {
+		rs := records[i]
+		name, err := symbolName(rs.Name, strtab)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to read symbol #%d's name", i)
+		}
+		symbols = append(symbols, &Symbol{
+			Name:               name,
+			Value:              rs.Value,
+			SectionNumber:      int16(rs.SectionNumber),
+			Type:               rs.Type,
+			StorageClass:       rs.StorageClass,
+			NumberOfAuxSymbols: rs.NumberOfAuxSymbols,
+		})
+		// auxiliary symbol records are skipped
+		i += int(rs.NumberOfAuxSymbols)
+	}
+	return records, symbols, nil
+}
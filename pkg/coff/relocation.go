@@ -15,3 +15,16 @@ type relocation struct {
 type Relocation interface {
 	VirtualAddress() uint32
 }
+
+// RawRelocation is a relocation read from an existing file.
+type RawRelocation struct {
+	VA               uint32
+	SymbolTableIndex uint32
+	Type             uint16
+}
+
+// VirtualAddress returns a virtual address where the relocation
+// should be applied to.
+func (r *RawRelocation) VirtualAddress() uint32 {
+	return r.VA
+}
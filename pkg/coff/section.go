@@ -1,6 +1,9 @@
 package coff
 
-import "io"
+import (
+	"bytes"
+	"io"
+)
 
 type rawSectionHeader struct {
 	Name                 [8]byte
@@ -16,8 +19,11 @@ type rawSectionHeader struct {
 }
 
 type section struct {
+	number            uint16 // 1-based section number
+	characteristics   uint32
 	dataOffset        uint32
 	relocationsOffset uint32
+	symbolIndex       uint32 // index of the section symbol
 	Section
 }
 
@@ -28,3 +34,57 @@ type Section interface {
 	Size() int
 	Relocations() []Relocation
 }
+
+// SectionDecoder decodes a section's raw data into a Section.
+type SectionDecoder func(data []byte, relocations []Relocation) (Section, error)
+
+var sectionDecoders = make(map[string]SectionDecoder)
+
+// RegisterSectionDecoder registers a decoder used by Parse to decode
+// sections named name. Packages that implement a specific section
+// usually call it in their init function.
+func RegisterSectionDecoder(name string, d SectionDecoder) {
+	sectionDecoders[name] = d
+}
+
+// RawSection is a section read from an existing file that has no
+// registered decoder.
+type RawSection struct {
+	name        string
+	data        []byte
+	relocations []Relocation
+}
+
+// NewRawSection returns a section named name that holds data as is.
+func NewRawSection(name string, data []byte, relocations []Relocation) *RawSection {
+	return &RawSection{
+		name:        name,
+		data:        data,
+		relocations: relocations,
+	}
+}
+
+// Name returns the section's name.
+func (s *RawSection) Name() string {
+	return s.name
+}
+
+// Data returns the section's raw data.
+func (s *RawSection) Data() []byte {
+	return s.data
+}
+
+// WriteTo writes section data to w.
+func (s *RawSection) WriteTo(w io.Writer) (int64, error) {
+	return io.Copy(w, bytes.NewReader(s.data))
+}
+
+// Size returns the section's size.
+func (s *RawSection) Size() int {
+	return len(s.data)
+}
+
+// Relocations returns relocations that should be applied to the section.
+func (s *RawSection) Relocations() []Relocation {
+	return s.relocations
+}
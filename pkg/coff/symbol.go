@@ -8,3 +8,13 @@ type rawSymbol struct {
 	StorageClass       uint8
 	NumberOfAuxSymbols uint8
 }
+
+// Symbol is a COFF symbol table entry read from an existing file.
+type Symbol struct {
+	Name               string
+	Value              uint32
+	SectionNumber      int16
+	Type               uint16
+	StorageClass       uint8
+	NumberOfAuxSymbols uint8
+}
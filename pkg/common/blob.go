@@ -29,6 +29,13 @@ func NewBlob(r io.Reader) (Blob, error) {
 	}, nil
 }
 
+// NewBlobFromBytes creates a blob that holds b.
+func NewBlobFromBytes(b []byte) Blob {
+	return &dataBlob{
+		data: b,
+	}
+}
+
 func (b *dataBlob) Read(p []byte) (int, error) {
 	n := copy(p[:], b.data[b.offset:])
 	b.offset += int64(n)
@@ -38,3 +45,8 @@ func (b *dataBlob) Read(p []byte) (int, error) {
 func (b *dataBlob) Size() int64 {
 	return int64(len(b.data))
 }
+
+// Bytes returns the whole data regardless of read offset.
+func (b *dataBlob) Bytes() []byte {
+	return b.data
+}
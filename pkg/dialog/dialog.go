@@ -0,0 +1,154 @@
+// Package dialog provides dialog template(RT_DIALOG) resource related
+// functionalities.
+package dialog
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/pkg/errors"
+)
+
+// predefined control classes and their ordinals
+var classOrdinals = map[string]uint16{
+	"button":    0x0080,
+	"edit":      0x0081,
+	"static":    0x0082,
+	"listbox":   0x0083,
+	"scrollbar": 0x0084,
+	"combobox":  0x0085,
+}
+
+// DLGTEMPLATEEX without variable-length fields
+type templateHeader struct {
+	DlgVer    uint16
+	Signature uint16
+	HelpID    uint32
+	ExStyle   uint32
+	Style     uint32
+	DlgItems  uint16
+	X         int16
+	Y         int16
+	CX        int16
+	CY        int16
+}
+
+// DLGITEMTEMPLATEEX without variable-length fields
+type itemHeader struct {
+	HelpID  uint32
+	ExStyle uint32
+	Style   uint32
+	X       int16
+	Y       int16
+	CX      int16
+	CY      int16
+	ID      uint32
+}
+
+// Template is an extended dialog template. Coordinates and sizes are
+// in dialog units.
+type Template struct {
+	HelpID   uint32
+	ExStyle  uint32
+	Style    uint32
+	X        int16
+	Y        int16
+	Width    int16
+	Height   int16
+	Class    string // empty for the predefined dialog box class
+	Title    string
+	Font     *Font // DS_SETFONT is set if given, and cleared otherwise
+	Controls []*Control
+}
+
+// Font is the font of a dialog and its controls.
+type Font struct {
+	PointSize uint16
+	Weight    uint16
+	Italic    bool
+	Charset   uint8
+	Typeface  string
+}
+
+// Control is a control in a dialog.
+type Control struct {
+	HelpID  uint32
+	ExStyle uint32
+	Style   uint32
+	X       int16
+	Y       int16
+	Width   int16
+	Height  int16
+	ID      uint32
+	Class   string // predefined class names are written as ordinals
+	Title   string
+}
+
+// WriteTo writes the template to w in DLGTEMPLATEEX format.
+func (t *Template) WriteTo(w io.Writer) (int64, error) {
+	if len(t.Controls) > 0xffff {
+		return 0, errors.Errorf("too many controls: %d", len(t.Controls))
+	}
+	buf := new(bytes.Buffer)
+	// DS_SETFONT tells that a font follows the title, so it must match
+	// whether Font is given.
+	style := t.Style &^ setFontStyle
+	if t.Font != nil {
+		style |= setFontStyle
+	}
+	common.BinaryWriteTo(buf, &templateHeader{
+		DlgVer:    1,
+		Signature: 0xffff,
+		HelpID:    t.HelpID,
+		ExStyle:   t.ExStyle,
+		Style:     style,
+		DlgItems:  uint16(len(t.Controls)),
+		X:         t.X,
+		Y:         t.Y,
+		CX:        t.Width,
+		CY:        t.Height,
+	})
+	writeString(buf, "") // no menu
+	writeString(buf, t.Class)
+	writeString(buf, t.Title)
+	if t.Font != nil {
+		var italic uint8
+		if t.Font.Italic {
+			italic = 1
+		}
+		common.BinaryWriteTo(buf, []uint16{t.Font.PointSize, t.Font.Weight})
+		common.BinaryWriteTo(buf, []uint8{italic, t.Font.Charset})
+		writeString(buf, t.Font.Typeface)
+	}
+	for _, c := range t.Controls {
+		common.WritePaddingTo(buf, (4-buf.Len()%4)%4) // items are aligned to 32 bits
+		common.BinaryWriteTo(buf, &itemHeader{
+			HelpID:  c.HelpID,
+			ExStyle: c.ExStyle,
+			Style:   c.Style,
+			X:       c.X,
+			Y:       c.Y,
+			CX:      c.Width,
+			CY:      c.Height,
+			ID:      c.ID,
+		})
+		if ord, ok := classOrdinals[strings.ToLower(c.Class)]; ok {
+			common.BinaryWriteTo(buf, []uint16{0xffff, ord})
+		} else {
+			writeString(buf, c.Class)
+		}
+		writeString(buf, c.Title)
+		common.BinaryWriteTo(buf, uint16(0)) // no creation data
+	}
+	return buf.WriteTo(w)
+}
+
+// writeString writes s as a null-terminated UTF-16 string. An empty
+// string becomes a single null character, which means no value for
+// sz_Or_Ord fields.
+func writeString(buf *bytes.Buffer, s string) {
+	common.BinaryWriteTo(buf, utf16.Encode([]rune(s+"\x00")))
+}
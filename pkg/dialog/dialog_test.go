@@ -0,0 +1,92 @@
+package dialog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	tmpl := &Template{
+		Style:  0x80000000,
+		Width:  100,
+		Height: 50,
+		Title:  "Hi",
+		Font:   &Font{PointSize: 8, Typeface: "MS Shell Dlg"},
+		Controls: []*Control{
+			{Style: 0x50000000, Width: 50, Height: 14, ID: 1, Class: "Button", Title: "OK"},
+			{Style: 0x50000000, Width: 50, Height: 14, ID: 2, Class: "SysLink", Title: "A"},
+		},
+	}
+	b := new(bytes.Buffer)
+	n, err := tmpl.WriteTo(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	if n != int64(len(data)) {
+		t.Fatalf("wrong written length; expected %d, got %d", len(data), n)
+	}
+	// header(26), menu(2), class(2), title(6), font(6+26),
+	// control #1(24+4+6+2), padding(0), control #2(24+16+4+2)
+	if len(data) != 68+36+46 {
+		t.Fatalf("wrong data length; expected %d, got %d", 68+36+46, len(data))
+	}
+	if ver, sig := binary.LittleEndian.Uint16(data), binary.LittleEndian.Uint16(data[2:]); ver != 1 || sig != 0xffff {
+		t.Fatalf("wrong version and signature; expected (1, 0xffff), got (%d, %#x)", ver, sig)
+	}
+	if style := binary.LittleEndian.Uint32(data[12:]); style != 0x80000040 {
+		t.Fatalf("wrong style; expected 0x80000040(with DS_SETFONT), got %#x", style)
+	}
+	if count := binary.LittleEndian.Uint16(data[16:]); count != 2 {
+		t.Fatalf("wrong control count; expected 2, got %d", count)
+	}
+	if class := binary.LittleEndian.Uint32(data[68+24:]); class != 0x0080ffff {
+		t.Fatalf("wrong control class; expected button ordinal, got %#x", class)
+	}
+	if id := binary.LittleEndian.Uint32(data[104+20:]); id != 2 {
+		t.Fatalf("wrong control id; expected 2, got %d", id)
+	}
+}
+
+func TestParseStyle(t *testing.T) {
+	for _, tc := range []struct {
+		Style      string
+		Value      uint32
+		ShouldFail bool
+	}{
+		{Style: "WS_POPUP | ws_caption|DS_MODALFRAME", Value: 0x80c00080},
+		{Style: "BS_DEFPUSHBUTTON | 0x10000", Value: 0x10001},
+		{Style: "WS_FOO", ShouldFail: true},
+		{Style: "WS_POPUP |", ShouldFail: true},
+	} {
+		v, err := ParseStyle(tc.Style)
+		if tc.ShouldFail {
+			if err == nil {
+				t.Fatalf("expected failure for %q, got no error", tc.Style)
+			}
+			continue
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if v != tc.Value {
+			t.Fatalf("wrong style for %q; expected %#x, got %#x", tc.Style, tc.Value, v)
+		}
+	}
+}
+
+func TestWriteTo_setFontWithoutFont(t *testing.T) {
+	tmpl := &Template{Style: 0x80000000 | setFontStyle, Width: 100, Height: 50}
+	b := new(bytes.Buffer)
+	if _, err := tmpl.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	// header(26), menu(2), class(2), title(2)
+	if len(data) != 32 {
+		t.Fatalf("wrong data length; expected 32, got %d", len(data))
+	}
+	if style := binary.LittleEndian.Uint32(data[12:]); style != 0x80000000 {
+		t.Fatalf("wrong style; expected 0x80000000(without DS_SETFONT), got %#x", style)
+	}
+}
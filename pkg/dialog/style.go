@@ -0,0 +1,193 @@
+package dialog
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const setFontStyle = 0x40 // DS_SETFONT
+
+// window, dialog and control styles by their names
+var styles = map[string]uint32{
+	"WS_OVERLAPPED":       0x00000000,
+	"WS_POPUP":            0x80000000,
+	"WS_CHILD":            0x40000000,
+	"WS_MINIMIZE":         0x20000000,
+	"WS_VISIBLE":          0x10000000,
+	"WS_DISABLED":         0x08000000,
+	"WS_CLIPSIBLINGS":     0x04000000,
+	"WS_CLIPCHILDREN":     0x02000000,
+	"WS_MAXIMIZE":         0x01000000,
+	"WS_CAPTION":          0x00c00000,
+	"WS_BORDER":           0x00800000,
+	"WS_DLGFRAME":         0x00400000,
+	"WS_VSCROLL":          0x00200000,
+	"WS_HSCROLL":          0x00100000,
+	"WS_SYSMENU":          0x00080000,
+	"WS_THICKFRAME":       0x00040000,
+	"WS_SIZEBOX":          0x00040000,
+	"WS_GROUP":            0x00020000,
+	"WS_TABSTOP":          0x00010000,
+	"WS_MINIMIZEBOX":      0x00020000,
+	"WS_MAXIMIZEBOX":      0x00010000,
+	"WS_OVERLAPPEDWINDOW": 0x00cf0000,
+	"WS_POPUPWINDOW":      0x80880000,
+
+	"WS_EX_DLGMODALFRAME":  0x00000001,
+	"WS_EX_NOPARENTNOTIFY": 0x00000004,
+	"WS_EX_TOPMOST":        0x00000008,
+	"WS_EX_ACCEPTFILES":    0x00000010,
+	"WS_EX_TRANSPARENT":    0x00000020,
+	"WS_EX_TOOLWINDOW":     0x00000080,
+	"WS_EX_WINDOWEDGE":     0x00000100,
+	"WS_EX_CLIENTEDGE":     0x00000200,
+	"WS_EX_CONTEXTHELP":    0x00000400,
+	"WS_EX_RIGHT":          0x00001000,
+	"WS_EX_RTLREADING":     0x00002000,
+	"WS_EX_LEFTSCROLLBAR":  0x00004000,
+	"WS_EX_CONTROLPARENT":  0x00010000,
+	"WS_EX_STATICEDGE":     0x00020000,
+	"WS_EX_APPWINDOW":      0x00040000,
+	"WS_EX_LAYOUTRTL":      0x00400000,
+
+	"DS_ABSALIGN":      0x0001,
+	"DS_SYSMODAL":      0x0002,
+	"DS_3DLOOK":        0x0004,
+	"DS_FIXEDSYS":      0x0008,
+	"DS_NOFAILCREATE":  0x0010,
+	"DS_LOCALEDIT":     0x0020,
+	"DS_SETFONT":       setFontStyle,
+	"DS_MODALFRAME":    0x0080,
+	"DS_NOIDLEMSG":     0x0100,
+	"DS_SETFOREGROUND": 0x0200,
+	"DS_CONTROL":       0x0400,
+	"DS_CENTER":        0x0800,
+	"DS_CENTERMOUSE":   0x1000,
+	"DS_CONTEXTHELP":   0x2000,
+	"DS_SHELLFONT":     0x0048,
+
+	"BS_PUSHBUTTON":      0x0000,
+	"BS_DEFPUSHBUTTON":   0x0001,
+	"BS_CHECKBOX":        0x0002,
+	"BS_AUTOCHECKBOX":    0x0003,
+	"BS_RADIOBUTTON":     0x0004,
+	"BS_3STATE":          0x0005,
+	"BS_AUTO3STATE":      0x0006,
+	"BS_GROUPBOX":        0x0007,
+	"BS_USERBUTTON":      0x0008,
+	"BS_AUTORADIOBUTTON": 0x0009,
+	"BS_OWNERDRAW":       0x000b,
+	"BS_SPLITBUTTON":     0x000c,
+	"BS_DEFSPLITBUTTON":  0x000d,
+	"BS_COMMANDLINK":     0x000e,
+	"BS_DEFCOMMANDLINK":  0x000f,
+	"BS_LEFTTEXT":        0x0020,
+	"BS_ICON":            0x0040,
+	"BS_BITMAP":          0x0080,
+	"BS_LEFT":            0x0100,
+	"BS_RIGHT":           0x0200,
+	"BS_CENTER":          0x0300,
+	"BS_TOP":             0x0400,
+	"BS_BOTTOM":          0x0800,
+	"BS_VCENTER":         0x0c00,
+	"BS_PUSHLIKE":        0x1000,
+	"BS_MULTILINE":       0x2000,
+	"BS_NOTIFY":          0x4000,
+	"BS_FLAT":            0x8000,
+
+	"ES_LEFT":        0x0000,
+	"ES_CENTER":      0x0001,
+	"ES_RIGHT":       0x0002,
+	"ES_MULTILINE":   0x0004,
+	"ES_UPPERCASE":   0x0008,
+	"ES_LOWERCASE":   0x0010,
+	"ES_PASSWORD":    0x0020,
+	"ES_AUTOVSCROLL": 0x0040,
+	"ES_AUTOHSCROLL": 0x0080,
+	"ES_NOHIDESEL":   0x0100,
+	"ES_OEMCONVERT":  0x0400,
+	"ES_READONLY":    0x0800,
+	"ES_WANTRETURN":  0x1000,
+	"ES_NUMBER":      0x2000,
+
+	"SS_LEFT":           0x0000,
+	"SS_CENTER":         0x0001,
+	"SS_RIGHT":          0x0002,
+	"SS_ICON":           0x0003,
+	"SS_BLACKRECT":      0x0004,
+	"SS_GRAYRECT":       0x0005,
+	"SS_WHITERECT":      0x0006,
+	"SS_BLACKFRAME":     0x0007,
+	"SS_GRAYFRAME":      0x0008,
+	"SS_WHITEFRAME":     0x0009,
+	"SS_SIMPLE":         0x000b,
+	"SS_LEFTNOWORDWRAP": 0x000c,
+	"SS_OWNERDRAW":      0x000d,
+	"SS_BITMAP":         0x000e,
+	"SS_ETCHEDHORZ":     0x0010,
+	"SS_ETCHEDVERT":     0x0011,
+	"SS_ETCHEDFRAME":    0x0012,
+	"SS_NOPREFIX":       0x0080,
+	"SS_NOTIFY":         0x0100,
+	"SS_CENTERIMAGE":    0x0200,
+	"SS_RIGHTJUST":      0x0400,
+	"SS_SUNKEN":         0x1000,
+	"SS_ENDELLIPSIS":    0x4000,
+	"SS_PATHELLIPSIS":   0x8000,
+	"SS_WORDELLIPSIS":   0xc000,
+
+	"LBS_NOTIFY":            0x0001,
+	"LBS_SORT":              0x0002,
+	"LBS_NOREDRAW":          0x0004,
+	"LBS_MULTIPLESEL":       0x0008,
+	"LBS_OWNERDRAWFIXED":    0x0010,
+	"LBS_OWNERDRAWVARIABLE": 0x0020,
+	"LBS_HASSTRINGS":        0x0040,
+	"LBS_USETABSTOPS":       0x0080,
+	"LBS_NOINTEGRALHEIGHT":  0x0100,
+	"LBS_MULTICOLUMN":       0x0200,
+	"LBS_WANTKEYBOARDINPUT": 0x0400,
+	"LBS_EXTENDEDSEL":       0x0800,
+	"LBS_DISABLENOSCROLL":   0x1000,
+	"LBS_NODATA":            0x2000,
+	"LBS_NOSEL":             0x4000,
+	"LBS_STANDARD":          0xa00003,
+
+	"CBS_SIMPLE":            0x0001,
+	"CBS_DROPDOWN":          0x0002,
+	"CBS_DROPDOWNLIST":      0x0003,
+	"CBS_OWNERDRAWFIXED":    0x0010,
+	"CBS_OWNERDRAWVARIABLE": 0x0020,
+	"CBS_AUTOHSCROLL":       0x0040,
+	"CBS_OEMCONVERT":        0x0080,
+	"CBS_SORT":              0x0100,
+	"CBS_HASSTRINGS":        0x0200,
+	"CBS_NOINTEGRALHEIGHT":  0x0400,
+	"CBS_DISABLENOSCROLL":   0x0800,
+	"CBS_UPPERCASE":         0x2000,
+	"CBS_LOWERCASE":         0x4000,
+
+	"SBS_HORZ": 0x0000,
+	"SBS_VERT": 0x0001,
+}
+
+// ParseStyle parses style names and numbers combined with "|", like
+// "WS_POPUP | WS_CAPTION | 0x80". Names are case-insensitive.
+func ParseStyle(s string) (uint32, error) {
+	var style uint32
+	for _, part := range strings.Split(s, "|") {
+		part = strings.TrimSpace(part)
+		if v, ok := styles[strings.ToUpper(part)]; ok {
+			style |= v
+			continue
+		}
+		v, err := strconv.ParseUint(part, 0, 32)
+		if err != nil {
+			return 0, errors.Errorf("unknown style: %q", part)
+		}
+		style |= uint32(v)
+	}
+	return style, nil
+}
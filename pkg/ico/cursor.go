@@ -0,0 +1,121 @@
+package ico
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/pkg/errors"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// CursorImage represents a single cursor image.
+type CursorImage struct {
+	ID       int // ID must be set manually
+	HotspotX uint16
+	HotspotY uint16
+	data     []byte
+	offset   int64
+}
+
+// Read copies cursor resource data, which is the hotspot followed by
+// image data, to p.
+func (i *CursorImage) Read(p []byte) (int, error) {
+	if i.offset == 0 && len(p) < binary.Size(&cursorHeader{}) {
+		return 0, errors.New("buffer is too small")
+	}
+	written := 0
+	if i.offset == 0 {
+		binary.LittleEndian.PutUint16(p, i.HotspotX)
+		binary.LittleEndian.PutUint16(p[2:], i.HotspotY)
+		written = binary.Size(&cursorHeader{})
+		i.offset = int64(written)
+	}
+	n := copy(p[written:], i.data[i.offset-int64(binary.Size(&cursorHeader{})):])
+	i.offset += int64(n)
+	return written + n, nil
+}
+
+// Size returns cursor resource's size.
+func (i *CursorImage) Size() int64 {
+	return int64(binary.Size(&cursorHeader{}) + len(i.data))
+}
+
+// CursorGroup represents a cursor group.
+type CursorGroup struct {
+	dir     *directory
+	entries []*directoryEntry
+	Images  []*CursorImage
+}
+
+func (g *CursorGroup) Read(p []byte) (int, error) {
+	buf := bytes.NewBuffer(p[:0])
+	written := 0
+	n, err := common.BinaryWriteTo(buf, &groupDirectory{
+		Type:  cursorType,
+		Count: uint16(len(g.entries)),
+	})
+	if err != nil {
+		return written, errors.Wrap(err, "failed to write cursor group directory")
+	}
+	written += int(n)
+	for i, e := range g.entries {
+		img := g.Images[i]
+		if img.ID == 0 {
+			return written, errors.Errorf("image #%d doesn't have an id", i)
+		}
+		width, height, planes, bitCount := cursorImageInfo(e, img.data)
+		n, err := common.BinaryWriteTo(buf, &cursorGroupDirectoryEntry{
+			Width:      width,
+			Height:     height,
+			Planes:     planes,
+			BitCount:   bitCount,
+			BytesInRes: uint32(img.Size()),
+			ID:         uint16(img.ID),
+		})
+		if err != nil {
+			return written, errors.Wrapf(err, "failed to write cursor group directory entry #%d", i)
+		}
+		written += int(n)
+	}
+	return written, nil
+}
+
+// Size returns total byte size when g treated as a Blob.
+func (g *CursorGroup) Size() int64 {
+	return int64(binary.Size(&groupDirectory{}) + len(g.entries)*binary.Size(&cursorGroupDirectoryEntry{}))
+}
+
+// cursorImageInfo returns width, height, planes and bit count of
+// a cursor image for its group directory entry. Like the resource
+// compiler does, height includes the AND mask, so it is doubled.
+func cursorImageInfo(e *directoryEntry, data []byte) (uint16, uint16, uint16, uint16) {
+	var h bitmapInfoHeader
+	if !bytes.HasPrefix(data, pngSignature) && binary.Read(bytes.NewReader(data), binary.LittleEndian, &h) == nil {
+		return uint16(h.Width), uint16(h.Height), h.Planes, h.BitCount
+	}
+	return uint16(dimension(e.Width)), uint16(dimension(e.Height)) * 2, 1, 32
+}
+
+// DecodeAllCursors reads a CUR file from r and returns representation
+// of the cursor group.
+func DecodeAllCursors(r Reader) (*CursorGroup, error) {
+	d, entries, datas, err := decodeFile(r, cursorType)
+	if err != nil {
+		return nil, err
+	}
+	var images []*CursorImage
+	for i, data := range datas {
+		images = append(images, &CursorImage{
+			HotspotX: entries[i].Planes, // CUR file stores hotspot in place of planes and bit count
+			HotspotY: entries[i].BitCount,
+			data:     data,
+		})
+	}
+	return &CursorGroup{
+		dir:     d,
+		entries: entries,
+		Images:  images,
+	}, nil
+}
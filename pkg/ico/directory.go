@@ -1,5 +1,11 @@
 package ico
 
+// resource types in ICONDIR
+const (
+	iconType   = 1
+	cursorType = 2
+)
+
 // ICONDIR
 type directory struct {
 	Reserved uint16
@@ -33,3 +39,34 @@ type groupDirectoryEntry struct {
 	BytesInRes uint32
 	ID         uint16
 }
+
+// CURSORDIR, in place of the first four fields of GRPICONDIRENTRY
+type cursorGroupDirectoryEntry struct {
+	Width      uint16
+	Height     uint16
+	Planes     uint16
+	BitCount   uint16
+	BytesInRes uint32
+	ID         uint16
+}
+
+// LOCALHEADER, which precedes image data in a cursor resource
+type cursorHeader struct {
+	HotspotX uint16
+	HotspotY uint16
+}
+
+// BITMAPINFOHEADER
+type bitmapInfoHeader struct {
+	Size          uint32
+	Width         int32
+	Height        int32
+	Planes        uint16
+	BitCount      uint16
+	Compression   uint32
+	SizeImage     uint32
+	XPelsPerMeter int32
+	YPelsPerMeter int32
+	ClrUsed       uint32
+	ClrImportant  uint32
+}
@@ -0,0 +1,83 @@
+package ico
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/pkg/errors"
+)
+
+// Encode writes g to w in ICO file format.
+func Encode(w io.Writer, g *Group) error {
+	if len(g.entries) != len(g.Images) {
+		return errors.New("number of entries and images mismatch")
+	}
+	if _, err := common.BinaryWriteTo(w, &directory{
+		Type:  iconType,
+		Count: uint16(len(g.entries)),
+	}); err != nil {
+		return errors.Wrap(err, "failed to write icon directory")
+	}
+	offset := uint32(binary.Size(&directory{}) + len(g.entries)*binary.Size(&directoryEntry{}))
+	for i, e := range g.entries {
+		size := uint32(len(g.Images[i].data))
+		if _, err := common.BinaryWriteTo(w, &directoryEntry{
+			Width:       e.Width,
+			Height:      e.Height,
+			ColorCount:  e.ColorCount,
+			Reserved:    e.Reserved,
+			Planes:      e.Planes,
+			BitCount:    e.BitCount,
+			BytesInRes:  size,
+			ImageOffset: offset,
+		}); err != nil {
+			return errors.Wrapf(err, "failed to write icon directory entry #%d", i)
+		}
+		offset += size
+	}
+	for i, img := range g.Images {
+		if _, err := w.Write(img.data); err != nil {
+			return errors.Wrapf(err, "failed to write icon image #%d", i)
+		}
+	}
+	return nil
+}
+
+// NewGroupFromResource assembles an icon group from icon group resource
+// data in b and icon image resources, which image returns by their ids.
+// The result can be written as an ICO file by Encode.
+func NewGroupFromResource(b []byte, image func(id int) ([]byte, error)) (*Group, error) {
+	entries, err := DecodeGroupResource(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.New("icon group resource has no entries")
+	}
+	g := &Group{
+		dir: &directory{
+			Type:  iconType,
+			Count: uint16(len(entries)),
+		},
+	}
+	for _, e := range entries {
+		data, err := image(e.ID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get icon image %d", e.ID)
+		}
+		g.entries = append(g.entries, &directoryEntry{
+			Width:      uint8(e.Width), // 256 becomes 0
+			Height:     uint8(e.Height),
+			ColorCount: e.ColorCount,
+			Planes:     e.Planes,
+			BitCount:   e.BitCount,
+			BytesInRes: uint32(len(data)),
+		})
+		g.Images = append(g.Images, &Image{
+			ID:   e.ID,
+			data: data,
+		})
+	}
+	return g, nil
+}
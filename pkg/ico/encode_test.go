@@ -0,0 +1,90 @@
+package ico
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestEncode(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "testdata", "icon.ico"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := new(bytes.Buffer)
+	if err := Encode(b, g); err != nil {
+		t.Fatal(err)
+	}
+	g2, err := DecodeAll(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g2.Images) != len(g.Images) {
+		t.Fatalf("wrong images length; expected %d, got %d", len(g.Images), len(g2.Images))
+	}
+	for i := range g.Images {
+		if *g2.entries[i] != *g.entries[i] {
+			t.Fatalf("wrong directory entry #%d; expected %+v, got %+v", i, g.entries[i], g2.entries[i])
+		}
+		if !bytes.Equal(g2.Images[i].data, g.Images[i].data) {
+			t.Fatalf("wrong image #%d data", i)
+		}
+	}
+}
+
+func TestNewGroupFromResource(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("..", "..", "testdata", "icon.ico"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := make(map[int][]byte)
+	for i, img := range g.Images {
+		img.ID = i + 1
+		images[img.ID] = img.data
+	}
+	b := make([]byte, g.Size())
+	if _, err := g.Read(b); err != nil {
+		t.Fatal(err)
+	}
+
+	g2, err := NewGroupFromResource(b, func(id int) ([]byte, error) {
+		data, ok := images[id]
+		if !ok {
+			return nil, errors.New("not found")
+		}
+		return data, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := new(bytes.Buffer)
+	if err := Encode(out, g2); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Fatal("extracted icon file differs from original")
+	}
+
+	if _, err := NewGroupFromResource(b, func(int) ([]byte, error) {
+		return nil, errors.New("not found")
+	}); err == nil {
+		t.Fatal("expected failure, got no error")
+	}
+	if _, err := NewGroupFromResource([]byte{0, 0, 1, 0, 0, 0}, func(int) ([]byte, error) {
+		return nil, nil
+	}); err == nil {
+		t.Fatal("expected failure for empty icon group, got no error")
+	}
+}
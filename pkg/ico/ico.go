@@ -29,6 +29,11 @@ func (i *Image) Size() int64 {
 	return int64(len(i.data))
 }
 
+// Bytes returns image data regardless of read offset.
+func (i *Image) Bytes() []byte {
+	return i.data
+}
+
 // Group represents an icon group.
 type Group struct {
 	dir     *directory
@@ -41,7 +46,7 @@ func (g *Group) Read(p []byte) (int, error) {
 	buf := bytes.NewBuffer(p[:0])
 	written := 0
 	n, err := common.BinaryWriteTo(buf, &groupDirectory{
-		Type:  1,
+		Type:  iconType,
 		Count: uint16(len(g.entries)),
 	})
 	if err != nil {
@@ -78,34 +83,102 @@ func (g *Group) Size() int64 {
 // DecodeAll reads an ICO file from r and returns representation
 // of the icon group.
 func DecodeAll(r Reader) (*Group, error) {
+	d, entries, datas, err := decodeFile(r, iconType)
+	if err != nil {
+		return nil, err
+	}
+	var images []*Image
+	for _, data := range datas {
+		images = append(images, &Image{
+			data: data,
+		})
+	}
+	return &Group{
+		dir:     d,
+		entries: entries,
+		Images:  images,
+	}, nil
+}
+
+// decodeFile reads an ICO or CUR file from r, whose type must be typ.
+func decodeFile(r Reader, typ uint16) (*directory, []*directoryEntry, [][]byte, error) {
 	var d directory
 	if err := binary.Read(r, binary.LittleEndian, &d); err != nil {
-		return nil, errors.Wrap(err, "failed to read icon directory")
+		return nil, nil, nil, errors.Wrap(err, "failed to read icon directory")
 	}
-	if d.Reserved != 0 || d.Type != 1 || d.Count == 0 {
-		return nil, errors.New("bad ICO file")
+	if d.Reserved != 0 || d.Type != typ || d.Count == 0 {
+		if typ == cursorType {
+			return nil, nil, nil, errors.New("bad CUR file")
+		}
+		return nil, nil, nil, errors.New("bad ICO file")
 	}
 
 	var entries []*directoryEntry
-	var images []*Image
+	var datas [][]byte
 	for i := uint16(0); i < d.Count; i++ {
 		var e directoryEntry
 		if err := binary.Read(r, binary.LittleEndian, &e); err != nil {
-			return nil, errors.Wrapf(err, "failed to read icon directory entry #%d", i)
+			return nil, nil, nil, errors.Wrapf(err, "failed to read icon directory entry #%d", i)
 		}
 		entries = append(entries, &e)
 		data, err := ioutil.ReadAll(io.NewSectionReader(r, int64(e.ImageOffset), int64(e.BytesInRes)))
 		if err != nil {
-			return nil, errors.Wrapf(err, "failed to read icon image #%d's data", i)
+			return nil, nil, nil, errors.Wrapf(err, "failed to read icon image #%d's data", i)
 		}
-		images = append(images, &Image{
-			data: data,
+		if err := validateImage(&e, data, typ == cursorType); err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "invalid image #%d(%dx%d)", i, dimension(e.Width), dimension(e.Height))
+		}
+		datas = append(datas, data)
+	}
+
+	return &d, entries, datas, nil
+}
+
+// GroupEntry describes an icon image in an icon group resource.
+type GroupEntry struct {
+	Width      int
+	Height     int
+	ColorCount uint8
+	Planes     uint16
+	BitCount   uint16
+	BytesInRes uint32
+	ID         int
+}
+
+// DecodeGroupResource decodes icon group resource data(GRPICONDIR)
+// in b, which refers to icon images by their resource ids.
+func DecodeGroupResource(b []byte) ([]*GroupEntry, error) {
+	r := bytes.NewReader(b)
+	var d groupDirectory
+	if err := binary.Read(r, binary.LittleEndian, &d); err != nil {
+		return nil, errors.Wrap(err, "failed to read icon group directory")
+	}
+	if d.Reserved != 0 || d.Type != iconType {
+		return nil, errors.New("bad icon group resource")
+	}
+	var entries []*GroupEntry
+	for i := uint16(0); i < d.Count; i++ {
+		var e groupDirectoryEntry
+		if err := binary.Read(r, binary.LittleEndian, &e); err != nil {
+			return nil, errors.Wrapf(err, "failed to read icon group directory entry #%d", i)
+		}
+		entries = append(entries, &GroupEntry{
+			Width:      dimension(e.Width),
+			Height:     dimension(e.Height),
+			ColorCount: e.ColorCount,
+			Planes:     e.Planes,
+			BitCount:   e.BitCount,
+			BytesInRes: e.BytesInRes,
+			ID:         int(e.ID),
 		})
 	}
+	return entries, nil
+}
 
-	return &Group{
-		dir:     &d,
-		entries: entries,
-		Images:  images,
-	}, nil
+// dimension returns actual width or height of an image, where 0 means 256.
+func dimension(v uint8) int {
+	if v == 0 {
+		return 256
+	}
+	return int(v)
 }
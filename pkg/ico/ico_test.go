@@ -1,6 +1,10 @@
 package ico
 
 import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
 	"io"
 	"os"
 	"path/filepath"
@@ -74,10 +78,149 @@ func TestDecodeAll_invalidReader(t *testing.T) {
 		&badReader{data: []byte{0, 0, 0, 0, 0, 0}},
 		&badReader{data: []byte{0, 0, 1, 0, 0, 0}},
 		&badReader{data: []byte{0, 0, 1, 0, 1, 0}},
-		// &badReader{data: []byte{0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+		&badReader{data: []byte{0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
 	} {
 		if _, err := DecodeAll(tc); err == nil {
 			t.Fatal("expected failure, got no error")
 		}
 	}
 }
+
+func TestDecodeGroupResource(t *testing.T) {
+	f, err := os.Open(filepath.Join("..", "..", "testdata", "icon.ico"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	g, err := DecodeAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, img := range g.Images {
+		img.ID = i + 1
+	}
+	b := make([]byte, g.Size())
+	if _, err := g.Read(b); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := DecodeGroupResource(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(g.entries) {
+		t.Fatalf("wrong entries length; expected %d, got %d", len(g.entries), len(entries))
+	}
+	for i, e := range entries {
+		if e.ID != i+1 {
+			t.Fatalf("wrong id; expected %d, got %d", i+1, e.ID)
+		}
+		if e.BytesInRes != g.entries[i].BytesInRes {
+			t.Fatalf("wrong bytes in res; expected %d, got %d", g.entries[i].BytesInRes, e.BytesInRes)
+		}
+	}
+}
+
+func TestDecodeAllCursors(t *testing.T) {
+	f, err := os.Open(filepath.Join("..", "..", "testdata", "cursor.cur"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	g, err := DecodeAllCursors(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Images) != 1 {
+		t.Fatalf("wrong images length; expected 1, got %d", len(g.Images))
+	}
+	img := g.Images[0]
+	if img.HotspotX != 5 || img.HotspotY != 7 {
+		t.Fatalf("wrong hotspot; expected (5, 7), got (%d, %d)", img.HotspotX, img.HotspotY)
+	}
+	img.ID = 1
+
+	b := make([]byte, img.Size())
+	if n, err := img.Read(b); err != nil {
+		t.Fatal(err)
+	} else if n != len(b) {
+		t.Fatalf("wrong read length; expected %d, got %d", len(b), n)
+	}
+	if !bytes.Equal(b[:4], []byte{5, 0, 7, 0}) || !bytes.Equal(b[4:], img.data) {
+		t.Fatal("wrong cursor resource data")
+	}
+
+	b = make([]byte, g.Size())
+	if _, err := g.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	var e cursorGroupDirectoryEntry
+	if err := binary.Read(bytes.NewReader(b[6:]), binary.LittleEndian, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Width != 32 || e.Height != 64 || e.Planes != 1 || e.BitCount != 32 || e.BytesInRes != uint32(img.Size()) || e.ID != 1 {
+		t.Fatalf("wrong cursor group directory entry; got %+v", e)
+	}
+}
+
+func TestDecodeAllCursors_icon(t *testing.T) {
+	f, err := os.Open(filepath.Join("..", "..", "testdata", "icon.ico"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := DecodeAllCursors(f); err == nil {
+		t.Fatal("expected failure, got no error")
+	}
+}
+
+func TestNewGroup(t *testing.T) {
+	var imgs []image.Image
+	for _, size := range []int{16, 33, 256} {
+		img := image.NewNRGBA(image.Rect(0, 0, size, size))
+		img.Set(0, 0, color.NRGBA{0x12, 0x34, 0x56, 0xff})
+		imgs = append(imgs, img)
+	}
+	g, err := NewGroup(imgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tc := range []struct {
+		Width uint8
+		PNG   bool
+		Size  int
+	}{
+		{16, false, 40 + 16*16*4 + 4*16},
+		{33, false, 40 + 33*33*4 + 8*33},
+		{0, true, 0},
+	} {
+		e := g.entries[i]
+		if e.Width != tc.Width || e.Height != tc.Width || e.BitCount != 32 || e.Planes != 1 {
+			t.Fatalf("wrong directory entry #%d; got %+v", i, e)
+		}
+		data := g.Images[i].data
+		if int(e.BytesInRes) != len(data) {
+			t.Fatalf("wrong bytes in res; expected %d, got %d", len(data), e.BytesInRes)
+		}
+		if isPNG := bytes.HasPrefix(data, pngSignature); isPNG != tc.PNG {
+			t.Fatalf("wrong image #%d format; expected png=%v", i, tc.PNG)
+		}
+		if !tc.PNG {
+			if len(data) != tc.Size {
+				t.Fatalf("wrong image #%d size; expected %d, got %d", i, tc.Size, len(data))
+			}
+			// top-left pixel is stored at the start of the last row
+			p := 40 + (int(tc.Width)-1)*int(tc.Width)*4
+			if !bytes.Equal(data[p:p+4], []byte{0x56, 0x34, 0x12, 0xff}) {
+				t.Fatalf("wrong pixel data; got %+q", data[p:p+4])
+			}
+		}
+	}
+
+	if _, err := NewGroup([]image.Image{image.NewNRGBA(image.Rect(0, 0, 257, 257))}); err == nil {
+		t.Fatal("expected failure for too large image, got no error")
+	}
+}
@@ -0,0 +1,98 @@
+package ico
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/pkg/errors"
+)
+
+// pngMinSize is the minimum image size from which images are stored
+// PNG-compressed, like other tools do. Smaller ones are stored as BMP
+// for compatibility.
+const pngMinSize = 256
+
+// NewGroup assembles an icon group from images. Each image must be
+// at most 256x256 in size, and is stored in 32-bit color.
+func NewGroup(imgs []image.Image) (*Group, error) {
+	if len(imgs) == 0 {
+		return nil, errors.New("no images given")
+	}
+	g := &Group{
+		dir: &directory{
+			Type:  iconType,
+			Count: uint16(len(imgs)),
+		},
+	}
+	for i, img := range imgs {
+		b := img.Bounds()
+		if b.Dx() < 1 || b.Dy() < 1 || b.Dx() > 256 || b.Dy() > 256 {
+			return nil, errors.Errorf("image #%d has invalid size %dx%d; it should be at most 256x256", i, b.Dx(), b.Dy())
+		}
+		var data []byte
+		var err error
+		if b.Dx() >= pngMinSize || b.Dy() >= pngMinSize {
+			data, err = encodePNG(img)
+		} else {
+			data, err = encodeDIB(img)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode image #%d", i)
+		}
+		g.entries = append(g.entries, &directoryEntry{
+			Width:      uint8(b.Dx()), // 256 becomes 0
+			Height:     uint8(b.Dy()),
+			Planes:     1,
+			BitCount:   32,
+			BytesInRes: uint32(len(data)),
+		})
+		g.Images = append(g.Images, &Image{
+			data: data,
+		})
+	}
+	return g, nil
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeDIB encodes img as a 32-bit device-independent bitmap with
+// an AND mask, without BITMAPFILEHEADER, as icon images are stored.
+func encodeDIB(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	maskStride := (w + 31) / 32 * 4 // each row is aligned to 32 bits
+	buf := new(bytes.Buffer)
+	if _, err := common.BinaryWriteTo(buf, &bitmapInfoHeader{
+		Size:      uint32(binary.Size(&bitmapInfoHeader{})),
+		Width:     int32(w),
+		Height:    int32(h * 2), // includes AND mask
+		Planes:    1,
+		BitCount:  32,
+		SizeImage: uint32(w*h*4 + maskStride*h),
+	}); err != nil {
+		return nil, err
+	}
+	mask := make([]byte, maskStride*h)
+	for y := h - 1; y >= 0; y-- { // bottom-up
+		row := (h - 1 - y) * maskStride
+		for x := 0; x < w; x++ {
+			c := color.NRGBAModel.Convert(img.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			buf.Write([]byte{c.B, c.G, c.R, c.A})
+			if c.A == 0 {
+				mask[row+x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+	}
+	buf.Write(mask)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,115 @@
+package ico
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultSizes are standard icon sizes used by Windows.
+var DefaultSizes = []int{16, 20, 24, 32, 40, 48, 64, 256}
+
+// NewGroupFromImage assembles an icon group by resampling a single
+// square image to each of sizes. DefaultSizes is used if sizes is empty.
+// The image must not be smaller than the largest size.
+func NewGroupFromImage(img image.Image, sizes []int) (*Group, error) {
+	if len(sizes) == 0 {
+		sizes = DefaultSizes
+	}
+	b := img.Bounds()
+	if b.Dx() != b.Dy() {
+		return nil, errors.Errorf("image should be square, got %dx%d", b.Dx(), b.Dy())
+	}
+	var imgs []image.Image
+	for _, size := range sizes {
+		if size < 1 || size > 256 {
+			return nil, errors.Errorf("invalid icon size: %d", size)
+		} else if size > b.Dx() {
+			return nil, errors.Errorf("image is too small(%dx%d) for icon size %d", b.Dx(), b.Dy(), size)
+		}
+		imgs = append(imgs, Resize(img, size, size))
+	}
+	return NewGroup(imgs)
+}
+
+type weight struct {
+	index int
+	value float64
+}
+
+// Resize resamples img to w x h using area averaging, which gives good
+// result for downscaling.
+func Resize(img image.Image, w, h int) *image.NRGBA {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+
+	// premultiplied pixels of source image
+	src := make([]float64, sw*sh*4)
+	for y := 0; y < sh; y++ {
+		for x := 0; x < sw; x++ {
+			r, g, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			p := src[(y*sw+x)*4:]
+			p[0], p[1], p[2], p[3] = float64(r), float64(g), float64(bl), float64(a)
+		}
+	}
+
+	// resample horizontally, then vertically
+	xws := weights(sw, w)
+	tmp := make([]float64, w*sh*4)
+	for y := 0; y < sh; y++ {
+		for x, ws := range xws {
+			p := tmp[(y*w+x)*4:]
+			for _, wt := range ws {
+				s := src[(y*sw+wt.index)*4:]
+				for c := 0; c < 4; c++ {
+					p[c] += s[c] * wt.value
+				}
+			}
+		}
+	}
+	yws := weights(sh, h)
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y, ws := range yws {
+		for x := 0; x < w; x++ {
+			var p [4]float64
+			for _, wt := range ws {
+				s := tmp[(wt.index*w+x)*4:]
+				for c := 0; c < 4; c++ {
+					p[c] += s[c] * wt.value
+				}
+			}
+			dst.SetNRGBA(x, y, unpremultiply(p))
+		}
+	}
+	return dst
+}
+
+// weights returns, for each destination index, source indices and
+// their weights which are proportional to overlapping area.
+func weights(srcN, dstN int) [][]weight {
+	scale := float64(srcN) / float64(dstN)
+	r := make([][]weight, dstN)
+	for d := range r {
+		start, end := float64(d)*scale, float64(d+1)*scale
+		for i := int(math.Floor(start)); i < int(math.Ceil(end)) && i < srcN; i++ {
+			overlap := math.Min(end, float64(i+1)) - math.Max(start, float64(i))
+			if overlap > 0 {
+				r[d] = append(r[d], weight{i, overlap / scale})
+			}
+		}
+	}
+	return r
+}
+
+func unpremultiply(p [4]float64) color.NRGBA {
+	a := p[3]
+	if a <= 0 {
+		return color.NRGBA{}
+	}
+	ch := func(v float64) uint8 {
+		return uint8(math.Min(255, math.Round(v/a*255)))
+	}
+	return color.NRGBA{ch(p[0]), ch(p[1]), ch(p[2]), uint8(math.Min(255, math.Round(a/0xffff*255)))}
+}
@@ -0,0 +1,71 @@
+package ico
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestResize(t *testing.T) {
+	red := color.NRGBA{0xff, 0, 0, 0xff}
+	blue := color.NRGBA{0, 0, 0xff, 0xff}
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if x < 2 {
+				img.SetNRGBA(x, y, red)
+			} else {
+				img.SetNRGBA(x, y, blue)
+			}
+		}
+	}
+
+	r := Resize(img, 2, 2)
+	if c := r.NRGBAAt(0, 1); c != red {
+		t.Fatalf("wrong pixel; expected %v, got %v", red, c)
+	}
+	if c := r.NRGBAAt(1, 0); c != blue {
+		t.Fatalf("wrong pixel; expected %v, got %v", blue, c)
+	}
+
+	r = Resize(img, 1, 1)
+	if c := r.NRGBAAt(0, 0); c != (color.NRGBA{0x80, 0, 0x80, 0xff}) {
+		t.Fatalf("wrong averaged pixel; got %v", c)
+	}
+
+	// transparent pixels must not darken the result
+	img.SetNRGBA(0, 0, color.NRGBA{})
+	r = Resize(img, 2, 2)
+	if c := r.NRGBAAt(0, 0); c.R != 0xff || c.A != 0xbf {
+		t.Fatalf("wrong pixel with transparency; got %v", c)
+	}
+}
+
+func TestNewGroupFromImage(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 512, 512))
+	g, err := NewGroupFromImage(img, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Images) != len(DefaultSizes) {
+		t.Fatalf("wrong images length; expected %d, got %d", len(DefaultSizes), len(g.Images))
+	}
+	for i, size := range DefaultSizes {
+		if w := dimension(g.entries[i].Width); w != size {
+			t.Fatalf("wrong image #%d width; expected %d, got %d", i, size, w)
+		}
+	}
+
+	for _, tc := range []struct {
+		Image image.Image
+		Sizes []int
+	}{
+		{image.NewNRGBA(image.Rect(0, 0, 128, 128)), nil},
+		{image.NewNRGBA(image.Rect(0, 0, 64, 32)), []int{16}},
+		{img, []int{0}},
+	} {
+		if _, err := NewGroupFromImage(tc.Image, tc.Sizes); err == nil {
+			t.Fatal("expected failure, got no error")
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package ico
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// validateImage checks that image data is a valid PNG image or a DIB
+// with an AND mask, and that it matches its directory entry. Cursor
+// entries hold a hotspot in place of planes and bit count, so they are
+// not compared.
+func validateImage(e *directoryEntry, data []byte, cursor bool) error {
+	width, height := dimension(e.Width), dimension(e.Height)
+	if len(data) != int(e.BytesInRes) {
+		return errors.Errorf("image data is truncated; expected %d bytes, got %d", e.BytesInRes, len(data))
+	}
+
+	if bytes.HasPrefix(data, pngSignature) {
+		w, h, bpp, err := pngInfo(data)
+		if err != nil {
+			return err
+		}
+		if w != width || h != height {
+			return errors.Errorf("size mismatch; entry says %dx%d, PNG image is %dx%d", width, height, w, h)
+		}
+		if !cursor && e.BitCount != 0 && int(e.BitCount) != bpp {
+			return errors.Errorf("bit count mismatch; entry says %d, PNG image has %d", e.BitCount, bpp)
+		}
+		return nil
+	}
+
+	var h bitmapInfoHeader
+	if err := binary.Read(bytes.NewReader(data), binary.LittleEndian, &h); err != nil {
+		return errors.New("image data is neither a PNG image nor a bitmap")
+	}
+	if h.Size < uint32(binary.Size(&h)) {
+		return errors.Errorf("invalid bitmap header size: %d", h.Size)
+	} else if int(h.Width) != width || int(h.Height) != height*2 {
+		return errors.Errorf("size mismatch; entry says %dx%d, bitmap is %dx%d(height should be doubled for AND mask)", width, height, h.Width, h.Height)
+	} else if h.Planes != 1 {
+		return errors.Errorf("invalid bitmap planes: %d", h.Planes)
+	} else if !cursor && e.BitCount != 0 && e.BitCount != h.BitCount {
+		return errors.Errorf("bit count mismatch; entry says %d, bitmap has %d", e.BitCount, h.BitCount)
+	}
+	switch h.BitCount {
+	case 1, 4, 8, 16, 24, 32:
+	default:
+		return errors.Errorf("invalid bitmap bit count: %d", h.BitCount)
+	}
+	if h.Compression != 0 { // BI_RGB
+		return errors.Errorf("unsupported bitmap compression: %d", h.Compression)
+	}
+
+	colors := int(h.ClrUsed)
+	if colors == 0 && h.BitCount <= 8 {
+		colors = 1 << h.BitCount
+	}
+	xorStride := (width*int(h.BitCount) + 31) / 32 * 4
+	andStride := (width + 31) / 32 * 4
+	if size := int(h.Size) + colors*4 + (xorStride+andStride)*height; len(data) < size {
+		return errors.Errorf("bitmap data is truncated; expected at least %d bytes, got %d", size, len(data))
+	}
+	return nil
+}
+
+// pngInfo returns width, height and bits per pixel of PNG image data
+// from its IHDR chunk.
+func pngInfo(data []byte) (int, int, int, error) {
+	ihdr := data[len(pngSignature):]
+	if len(ihdr) < 8+13 || binary.BigEndian.Uint32(ihdr) != 13 || string(ihdr[4:8]) != "IHDR" {
+		return 0, 0, 0, errors.New("PNG image has no valid IHDR chunk")
+	}
+	w := int(binary.BigEndian.Uint32(ihdr[8:]))
+	h := int(binary.BigEndian.Uint32(ihdr[12:]))
+	depth, colorType := int(ihdr[16]), ihdr[17]
+	var channels int
+	switch colorType {
+	case 0, 3: // grayscale, indexed
+		channels = 1
+	case 2: // RGB
+		channels = 3
+	case 4: // grayscale with alpha
+		channels = 2
+	case 6: // RGBA
+		channels = 4
+	default:
+		return 0, 0, 0, errors.Errorf("invalid PNG color type: %d", colorType)
+	}
+	return w, h, depth * channels, nil
+}
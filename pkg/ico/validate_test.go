@@ -0,0 +1,72 @@
+package ico
+
+import (
+	"bytes"
+	"image"
+	"strings"
+	"testing"
+)
+
+func TestValidateImage(t *testing.T) {
+	g, err := NewGroup([]image.Image{
+		image.NewNRGBA(image.Rect(0, 0, 16, 16)),
+		image.NewNRGBA(image.Rect(0, 0, 256, 256)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dib, png := g.Images[0].data, g.Images[1].data
+	entry := func(size uint8, bitCount uint16, data []byte) *directoryEntry {
+		return &directoryEntry{Width: size, Height: size, Planes: 1, BitCount: bitCount, BytesInRes: uint32(len(data))}
+	}
+
+	for i, tc := range []struct {
+		Entry      *directoryEntry
+		Data       []byte
+		Cursor     bool
+		ShouldFail bool
+	}{
+		{Entry: entry(16, 32, dib), Data: dib},
+		{Entry: entry(16, 0, dib), Data: dib},
+		{Entry: entry(0, 32, png), Data: png},
+		{Entry: entry(16, 5, dib), Data: dib, Cursor: true},
+		{Entry: entry(32, 32, dib), Data: dib, ShouldFail: true},
+		{Entry: entry(16, 8, dib), Data: dib, ShouldFail: true},
+		{Entry: entry(16, 32, dib[:100]), Data: dib[:100], ShouldFail: true},
+		{Entry: entry(16, 32, dib), Data: dib[:100], ShouldFail: true},
+		{Entry: entry(48, 32, png), Data: png, ShouldFail: true},
+		{Entry: entry(0, 24, png), Data: png, ShouldFail: true},
+		{Entry: entry(0, 32, png[:20]), Data: png[:20], ShouldFail: true},
+		{Entry: entry(16, 32, []byte("garbage")), Data: []byte("garbage"), ShouldFail: true},
+	} {
+		err := validateImage(tc.Entry, tc.Data, tc.Cursor)
+		if tc.ShouldFail && err == nil {
+			t.Fatalf("expected failure for case #%d, got no error", i)
+		} else if !tc.ShouldFail && err != nil {
+			t.Fatalf("case #%d: %v", i, err)
+		}
+	}
+}
+
+func TestDecodeAll_invalidImage(t *testing.T) {
+	g, err := NewGroup([]image.Image{
+		image.NewNRGBA(image.Rect(0, 0, 16, 16)),
+		image.NewNRGBA(image.Rect(0, 0, 32, 32)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.entries[1].Width = 48
+
+	b := new(bytes.Buffer)
+	if err := Encode(b, g); err != nil {
+		t.Fatal(err)
+	}
+	_, err = DecodeAll(bytes.NewReader(b.Bytes()))
+	if err == nil {
+		t.Fatal("expected failure, got no error")
+	}
+	if !strings.Contains(err.Error(), "image #1(48x32)") {
+		t.Fatalf("error doesn't name the entry: %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+// Package manifest provides application manifest related functionalities.
+package manifest
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/pkg/errors"
+)
+
+// XML namespaces of windows settings
+const (
+	settings2005Namespace = "http://schemas.microsoft.com/SMI/2005/WindowsSettings"
+	settings2016Namespace = "http://schemas.microsoft.com/SMI/2016/WindowsSettings"
+	settings2019Namespace = "http://schemas.microsoft.com/SMI/2019/WindowsSettings"
+)
+
+// supported OS GUIDs by their names
+var supportedOSIDs = map[string]string{
+	"vista": "{e2011457-1546-43c5-a5fe-008deee3d3f0}",
+	"7":     "{35138b9a-5d96-4fbd-8e2d-a2440225f93a}",
+	"8":     "{4a2f28e3-53b9-4441-ba9c-d69d4a4a6e38}",
+	"8.1":   "{1f676c76-80e1-4239-95bb-83d0f6d0da78}",
+	"10":    "{8e0f7a12-bfb3-4fe8-b9a5-48fd50a15a9a}", // also for Windows 11
+}
+
+// AllSupportedOS is the list of all operating system names that can
+// be declared as supported.
+var AllSupportedOS = []string{"vista", "7", "8", "8.1", "10"}
+
+// dpiAware and dpiAwareness values by DPI awareness names
+var dpiAwarenessValues = map[string][2]string{
+	"unaware":      {"false", "unaware"},
+	"system":       {"true", "system"},
+	"permonitor":   {"true/pm", "PerMonitor"},
+	"permonitorv2": {"true/pm", "PerMonitorV2, PerMonitor"},
+}
+
+var executionLevels = map[string]bool{
+	"asInvoker":            true,
+	"highestAvailable":     true,
+	"requireAdministrator": true,
+}
+
+// Options holds settings of a generated manifest. Zero values leave
+// corresponding elements out.
+type Options struct {
+	Name           string // assembly identity name
+	Version        string // assembly identity version, like "1.2.3.4"
+	ExecutionLevel string // asInvoker, highestAvailable or requireAdministrator
+	UIAccess       bool
+	DPIAwareness   string // unaware, system, permonitor or permonitorv2
+	LongPathAware  bool
+	UTF8           bool     // use UTF-8 as active code page
+	SupportedOS    []string // vista, 7, 8, 8.1 or 10
+	CommonControls bool     // depend on Common Controls version 6
+}
+
+// Validate returns an error if the options are invalid.
+func (o *Options) Validate() error {
+	if o.Name == "" && o.Version != "" {
+		return errors.New("version is given without name")
+	} else if o.Version != "" {
+		if _, err := common.ParseVersionString(o.Version); err != nil {
+			return errors.Wrap(err, "invalid version")
+		}
+	}
+	if o.ExecutionLevel != "" && !executionLevels[o.ExecutionLevel] {
+		return errors.Errorf("invalid execution level: %q", o.ExecutionLevel)
+	} else if o.UIAccess && o.ExecutionLevel == "" {
+		return errors.New("ui access is given without execution level")
+	}
+	if o.DPIAwareness != "" {
+		if _, ok := dpiAwarenessValues[strings.ToLower(o.DPIAwareness)]; !ok {
+			return errors.Errorf("invalid DPI awareness: %q", o.DPIAwareness)
+		}
+	}
+	for _, os := range o.SupportedOS {
+		if _, ok := supportedOSIDs[strings.ToLower(os)]; !ok {
+			return errors.Errorf("unknown operating system: %q", os)
+		}
+	}
+	return nil
+}
+
+type assemblyIdentity struct {
+	Type                  string `xml:"type,attr"`
+	Name                  string `xml:"name,attr"`
+	Version               string `xml:"version,attr"`
+	ProcessorArchitecture string `xml:"processorArchitecture,attr"`
+	PublicKeyToken        string `xml:"publicKeyToken,attr,omitempty"`
+	Language              string `xml:"language,attr,omitempty"`
+}
+
+type assembly struct {
+	XMLName         xml.Name          `xml:"urn:schemas-microsoft-com:asm.v1 assembly"`
+	ManifestVersion string            `xml:"manifestVersion,attr"`
+	Identity        *assemblyIdentity `xml:"assemblyIdentity"`
+	Dependency      *assemblyIdentity `xml:"dependency>dependentAssembly>assemblyIdentity"`
+	TrustInfo       *trustInfo
+	Compatibility   *compatibility
+	Application     *application
+}
+
+type trustInfo struct {
+	XMLName xml.Name                `xml:"urn:schemas-microsoft-com:asm.v3 trustInfo"`
+	Level   requestedExecutionLevel `xml:"security>requestedPrivileges>requestedExecutionLevel"`
+}
+
+type requestedExecutionLevel struct {
+	Level    string `xml:"level,attr"`
+	UIAccess bool   `xml:"uiAccess,attr"`
+}
+
+type compatibility struct {
+	XMLName     xml.Name      `xml:"urn:schemas-microsoft-com:compatibility.v1 compatibility"`
+	SupportedOS []supportedOS `xml:"application>supportedOS"`
+}
+
+type supportedOS struct {
+	ID string `xml:"Id,attr"`
+}
+
+type application struct {
+	XMLName  xml.Name         `xml:"urn:schemas-microsoft-com:asm.v3 application"`
+	Settings []windowsSetting `xml:"windowsSettings>setting"`
+}
+
+// windowsSetting is an element in windowsSettings, whose name and
+// namespace are given by XMLName.
+type windowsSetting struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// Generate generates a manifest from o.
+func Generate(o *Options) ([]byte, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	a := &assembly{ManifestVersion: "1.0"}
+	if o.Name != "" {
+		version := o.Version
+		if version == "" {
+			version = "1.0.0.0"
+		}
+		a.Identity = &assemblyIdentity{
+			Type:                  "win32",
+			Name:                  o.Name,
+			Version:               version,
+			ProcessorArchitecture: "*",
+		}
+	}
+	if o.CommonControls {
+		a.Dependency = &assemblyIdentity{
+			Type:                  "win32",
+			Name:                  "Microsoft.Windows.Common-Controls",
+			Version:               "6.0.0.0",
+			ProcessorArchitecture: "*",
+			PublicKeyToken:        "6595b64144ccf1df",
+			Language:              "*",
+		}
+	}
+	if o.ExecutionLevel != "" {
+		a.TrustInfo = &trustInfo{
+			Level: requestedExecutionLevel{o.ExecutionLevel, o.UIAccess},
+		}
+	}
+	if len(o.SupportedOS) > 0 {
+		a.Compatibility = &compatibility{}
+		for _, os := range o.SupportedOS {
+			a.Compatibility.SupportedOS = append(a.Compatibility.SupportedOS, supportedOS{supportedOSIDs[strings.ToLower(os)]})
+		}
+	}
+
+	var settings []windowsSetting
+	if o.DPIAwareness != "" {
+		v := dpiAwarenessValues[strings.ToLower(o.DPIAwareness)]
+		settings = append(settings,
+			windowsSetting{xml.Name{Space: settings2005Namespace, Local: "dpiAware"}, v[0]},
+			windowsSetting{xml.Name{Space: settings2016Namespace, Local: "dpiAwareness"}, v[1]})
+	}
+	if o.LongPathAware {
+		settings = append(settings, windowsSetting{xml.Name{Space: settings2016Namespace, Local: "longPathAware"}, "true"})
+	}
+	if o.UTF8 {
+		settings = append(settings, windowsSetting{xml.Name{Space: settings2019Namespace, Local: "activeCodePage"}, "UTF-8"})
+	}
+	if len(settings) > 0 {
+		a.Application = &application{Settings: settings}
+	}
+
+	b := new(bytes.Buffer)
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	enc := xml.NewEncoder(b)
+	enc.Indent("", "  ")
+	if err := enc.Encode(a); err != nil {
+		return nil, errors.Wrap(err, "failed to encode manifest")
+	}
+	b.WriteString("\n")
+	return b.Bytes(), nil
+}
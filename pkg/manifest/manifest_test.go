@@ -0,0 +1,65 @@
+package manifest
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	b, err := Generate(&Options{
+		Name:           "Foo.Bar",
+		Version:        "1.2.3.4",
+		ExecutionLevel: "requireAdministrator",
+		DPIAwareness:   "PerMonitorV2",
+		LongPathAware:  true,
+		UTF8:           true,
+		SupportedOS:    AllSupportedOS,
+		CommonControls: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(b); err != nil {
+		t.Fatalf("generated manifest is invalid: %v", err)
+	}
+	for _, s := range []string{
+		`<assemblyIdentity type="win32" name="Foo.Bar" version="1.2.3.4" processorArchitecture="*">`,
+		`name="Microsoft.Windows.Common-Controls" version="6.0.0.0"`,
+		`<requestedExecutionLevel level="requireAdministrator" uiAccess="false">`,
+		`<supportedOS Id="{8e0f7a12-bfb3-4fe8-b9a5-48fd50a15a9a}">`,
+		`<dpiAwareness xmlns="http://schemas.microsoft.com/SMI/2016/WindowsSettings">PerMonitorV2, PerMonitor</dpiAwareness>`,
+		`<longPathAware xmlns="http://schemas.microsoft.com/SMI/2016/WindowsSettings">true</longPathAware>`,
+		`<activeCodePage xmlns="http://schemas.microsoft.com/SMI/2019/WindowsSettings">UTF-8</activeCodePage>`,
+	} {
+		if !bytes.Contains(b, []byte(s)) {
+			t.Fatalf("generated manifest doesn't contain %s:\n%s", s, b)
+		}
+	}
+}
+
+func TestGenerate_empty(t *testing.T) {
+	b, err := Generate(&Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"assemblyIdentity", "trustInfo", "compatibility", "windowsSettings"} {
+		if bytes.Contains(b, []byte(s)) {
+			t.Fatalf("empty manifest contains %s:\n%s", s, b)
+		}
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	for i, o := range []*Options{
+		{Version: "1.0.0.0"},
+		{Name: "Foo", Version: "1.0"},
+		{ExecutionLevel: "admin"},
+		{UIAccess: true},
+		{DPIAwareness: "permonitorv3"},
+		{SupportedOS: []string{"xp"}},
+	} {
+		if err := o.Validate(); err == nil {
+			t.Fatalf("expected failure for case #%d, got no error", i)
+		}
+	}
+}
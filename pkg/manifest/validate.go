@@ -0,0 +1,243 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+// XML namespaces of assembly manifests
+const (
+	asmV1Namespace         = "urn:schemas-microsoft-com:asm.v1"
+	asmV2Namespace         = "urn:schemas-microsoft-com:asm.v2"
+	asmV3Namespace         = "urn:schemas-microsoft-com:asm.v3"
+	compatibilityNamespace = "urn:schemas-microsoft-com:compatibility.v1"
+)
+
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+var (
+	guidPattern     = regexp.MustCompile(`^\{[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\}$`)
+	settingsPattern = regexp.MustCompile(`^http://schemas\.microsoft\.com/SMI/\d{4}/WindowsSettings$`)
+)
+
+// parent element names of known elements
+var knownParents = map[string]string{
+	"trustInfo":               "assembly",
+	"requestedExecutionLevel": "requestedPrivileges",
+	"compatibility":           "assembly",
+	"supportedOS":             "application",
+	"windowsSettings":         "application",
+}
+
+// windows settings whose value is either true or false
+var booleanSettings = map[string]bool{
+	"autoElevate":                       true,
+	"disableTheming":                    true,
+	"disableWindowFiltering":            true,
+	"gdiScaling":                        true,
+	"highResolutionScrollingAware":      true,
+	"longPathAware":                     true,
+	"printerDriverIsolation":            true,
+	"ultraHighResolutionScrollingAware": true,
+}
+
+var dpiAwareValues = map[string]bool{
+	"true":        true,
+	"false":       true,
+	"true/pm":     true,
+	"per monitor": true,
+}
+
+// StripBOM returns b without leading UTF-8 byte order mark.
+func StripBOM(b []byte) []byte {
+	return bytes.TrimPrefix(b, utf8BOM)
+}
+
+// encodingPattern matches the encoding declaration of an XML document.
+var encodingPattern = regexp.MustCompile(`^<\?xml[^>]*\sencoding\s*=\s*["']([^"']*)["']`)
+
+// toUTF8 converts a manifest in b to UTF-8 for validation. UTF-16 with
+// a byte order mark is decoded. Other encodings than UTF-8 are decoded
+// as ISO-8859-1, which keeps ASCII characters, and so all names and
+// values that are validated, intact.
+func toUTF8(b []byte) []byte {
+	if len(b) >= 2 && (b[0] == 0xff && b[1] == 0xfe || b[0] == 0xfe && b[1] == 0xff) {
+		order := binary.ByteOrder(binary.LittleEndian)
+		if b[0] == 0xfe {
+			order = binary.BigEndian
+		}
+		u := make([]uint16, (len(b)-2)/2)
+		for i := range u {
+			u[i] = order.Uint16(b[2+i*2:])
+		}
+		return []byte(string(utf16.Decode(u)))
+	}
+	b = StripBOM(b)
+	m := encodingPattern.FindSubmatch(b)
+	if m == nil || strings.EqualFold(string(m[1]), "utf-8") {
+		return b
+	}
+	r := make([]rune, len(b))
+	for i, c := range b {
+		r[i] = rune(c)
+	}
+	return []byte(string(r))
+}
+
+// element is an open element while validating.
+type element struct {
+	xml.StartElement
+	line int
+	text strings.Builder
+}
+
+// Validate parses b as a manifest and returns an error if it is
+// malformed, or one of known elements is invalid. Errors of known
+// elements are prefixed with their line numbers. Manifests in other
+// encodings than UTF-8 are accepted, but only their ASCII characters
+// are validated.
+func Validate(b []byte) error {
+	b = toUTF8(b)
+	d := xml.NewDecoder(bytes.NewReader(b))
+	d.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) {
+		return r, nil // already converted by toUTF8
+	}
+	var stack []*element
+	hasRoot := false
+	for {
+		offset := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.Wrap(err, "malformed XML")
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			e := &element{
+				StartElement: tok.Copy(),
+				line:         1 + bytes.Count(b[:offset], []byte("\n")),
+			}
+			var parent *element
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			} else if hasRoot {
+				return errors.Errorf("line %d: multiple root elements", e.line)
+			}
+			if err := validateElement(e, parent); err != nil {
+				return errors.Wrapf(err, "line %d", e.line)
+			}
+			stack = append(stack, e)
+			hasRoot = true
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(tok)
+			}
+		case xml.EndElement:
+			e := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 && stack[len(stack)-1].Name.Local == "windowsSettings" {
+				if err := validateSetting(e.Name.Local, e.text.String()); err != nil {
+					return errors.Wrapf(err, "line %d", e.line)
+				}
+			}
+		}
+	}
+	if !hasRoot {
+		return errors.New("no root element")
+	}
+	return nil
+}
+
+func validateElement(e, parent *element) error {
+	name := e.Name.Local
+	if parent == nil {
+		if name != "assembly" || e.Name.Space != asmV1Namespace {
+			return errors.Errorf("root element must be assembly in namespace %q, got %s in namespace %q", asmV1Namespace, name, e.Name.Space)
+		} else if v := attr(e, "manifestVersion"); v != "1.0" {
+			return errors.Errorf("manifestVersion must be \"1.0\", got %q", v)
+		}
+		return nil
+	}
+	if p, ok := knownParents[name]; ok && parent.Name.Local != p {
+		return errors.Errorf("%s must be in %s, not in %s", name, p, parent.Name.Local)
+	}
+	switch name {
+	case "trustInfo":
+		if e.Name.Space != asmV2Namespace && e.Name.Space != asmV3Namespace {
+			return errors.Errorf("trustInfo has unknown namespace %q", e.Name.Space)
+		}
+	case "requestedExecutionLevel":
+		if v := attr(e, "level"); !executionLevels[v] {
+			return errors.Errorf("invalid execution level: %q", v)
+		}
+		if v, ok := lookupAttr(e, "uiAccess"); ok && v != "true" && v != "false" {
+			return errors.Errorf("invalid uiAccess: %q", v)
+		}
+	case "compatibility":
+		if e.Name.Space != compatibilityNamespace {
+			return errors.Errorf("compatibility has unknown namespace %q", e.Name.Space)
+		}
+	case "supportedOS":
+		if v := attr(e, "Id"); !guidPattern.MatchString(v) {
+			return errors.Errorf("invalid supportedOS id: %q", v)
+		}
+	case "windowsSettings":
+		if e.Name.Space != asmV3Namespace && !settingsPattern.MatchString(e.Name.Space) {
+			return errors.Errorf("windowsSettings has unknown namespace %q", e.Name.Space)
+		}
+	default:
+		if parent.Name.Local == "windowsSettings" && !settingsPattern.MatchString(e.Name.Space) {
+			return errors.Errorf("setting %s has unknown namespace %q", name, e.Name.Space)
+		}
+	}
+	return nil
+}
+
+// validateSetting validates value of known windows settings. Unknown
+// settings are not validated.
+func validateSetting(name, value string) error {
+	v := strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case booleanSettings[name]:
+		if v != "true" && v != "false" {
+			return errors.Errorf("%s must be true or false, got %q", name, value)
+		}
+	case name == "dpiAware":
+		if !dpiAwareValues[v] {
+			return errors.Errorf("invalid dpiAware: %q", value)
+		}
+	case name == "dpiAwareness":
+		for _, s := range strings.Split(v, ",") {
+			if _, ok := dpiAwarenessValues[strings.TrimSpace(s)]; !ok {
+				return errors.Errorf("invalid dpiAwareness: %q", value)
+			}
+		}
+	case name == "activeCodePage":
+		if v == "" {
+			return errors.New("activeCodePage is empty")
+		}
+	}
+	return nil
+}
+
+func attr(e *element, name string) string {
+	v, _ := lookupAttr(e, name)
+	return v
+}
+
+func lookupAttr(e *element, name string) (string, bool) {
+	for _, a := range e.Attr {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,107 @@
+package manifest
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+const validManifest = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<assembly xmlns="urn:schemas-microsoft-com:asm.v1" manifestVersion="1.0">
+  <trustInfo xmlns="urn:schemas-microsoft-com:asm.v2">
+    <security>
+      <requestedPrivileges>
+        <requestedExecutionLevel level="asInvoker" uiAccess="false"/>
+      </requestedPrivileges>
+    </security>
+  </trustInfo>
+  <compatibility xmlns="urn:schemas-microsoft-com:compatibility.v1">
+    <application>
+      <supportedOS Id="{8e0f7a12-bfb3-4fe8-b9a5-48fd50a15a9a}"/>
+    </application>
+  </compatibility>
+  <application xmlns="urn:schemas-microsoft-com:asm.v3">
+    <windowsSettings>
+      <dpiAware xmlns="http://schemas.microsoft.com/SMI/2005/WindowsSettings">true/pm</dpiAware>
+      <dpiAwareness xmlns="http://schemas.microsoft.com/SMI/2016/WindowsSettings">PerMonitorV2, PerMonitor</dpiAwareness>
+      <longPathAware xmlns="http://schemas.microsoft.com/SMI/2016/WindowsSettings">true</longPathAware>
+    </windowsSettings>
+  </application>
+</assembly>
+`
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]byte(validManifest)); err != nil {
+		t.Fatal(err)
+	}
+	if err := Validate(append([]byte{0xef, 0xbb, 0xbf}, validManifest...)); err != nil {
+		t.Fatalf("manifest with BOM is invalid: %v", err)
+	}
+}
+
+func TestValidate_encodings(t *testing.T) {
+	utf16Manifest := func(s string, order binary.ByteOrder, bom []byte) []byte {
+		b := append([]byte{}, bom...)
+		for _, c := range utf16.Encode([]rune(s)) {
+			b = append(b, 0, 0)
+			order.PutUint16(b[len(b)-2:], c)
+		}
+		return b
+	}
+	latin1 := strings.Replace(validManifest, `encoding="UTF-8"`, `encoding="ISO-8859-1"`, 1)
+	latin1 = strings.Replace(latin1, "<security>", "<!-- caf\xe9 --><security>", 1)
+	wide := strings.Replace(validManifest, `encoding="UTF-8"`, `encoding="UTF-16"`, 1)
+	for _, tc := range []struct {
+		Name string
+		Data []byte
+	}{
+		{"ISO-8859-1", []byte(latin1)},
+		{"windows-1252", []byte(strings.Replace(latin1, "ISO-8859-1", "windows-1252", 1))},
+		{"UTF-16LE", utf16Manifest(wide, binary.LittleEndian, []byte{0xff, 0xfe})},
+		{"UTF-16BE", utf16Manifest(wide, binary.BigEndian, []byte{0xfe, 0xff})},
+	} {
+		if err := Validate(tc.Data); err != nil {
+			t.Fatalf("%s manifest is invalid: %v", tc.Name, err)
+		}
+	}
+
+	bad := strings.Replace(wide, "asInvoker", "admin", 1)
+	err := Validate(utf16Manifest(bad, binary.LittleEndian, []byte{0xff, 0xfe}))
+	if err == nil || !strings.HasPrefix(err.Error(), "line 6: invalid execution level") {
+		t.Fatalf("wrong error for invalid UTF-16 manifest; got %v", err)
+	}
+}
+
+func TestValidate_invalid(t *testing.T) {
+	for _, tc := range []struct {
+		Old, New string
+		Error    string
+	}{
+		{"</assembly>", "", "malformed XML"},
+		{"</assembly>", "</assembly><assembly/>", "line 22: multiple root elements"},
+		{"urn:schemas-microsoft-com:asm.v1", "urn:schemas-microsoft-com:asm.v2", "line 2: root element must be assembly"},
+		{`manifestVersion="1.0"`, "", `line 2: manifestVersion must be "1.0"`},
+		{"urn:schemas-microsoft-com:asm.v2", "urn:schemas-microsoft-com:asm.v4", "line 3: trustInfo has unknown namespace"},
+		{`level="asInvoker"`, `level="admin"`, `line 6: invalid execution level: "admin"`},
+		{`uiAccess="false"`, `uiAccess="no"`, `line 6: invalid uiAccess: "no"`},
+		{"<security>", "<security><requestedExecutionLevel level=\"asInvoker\"/>", "line 4: requestedExecutionLevel must be in requestedPrivileges, not in security"},
+		{"compatibility.v1", "compatibility.v2", "line 10: compatibility has unknown namespace"},
+		{"{8e0f7a12-bfb3-4fe8-b9a5-48fd50a15a9a}", "8e0f7a12", `line 12: invalid supportedOS id: "8e0f7a12"`},
+		{"SMI/2005/WindowsSettings", "SMI/WindowsSettings", "line 17: setting dpiAware has unknown namespace"},
+		{"true/pm", "yes", `line 17: invalid dpiAware: "yes"`},
+		{"PerMonitorV2, PerMonitor", "PerMonitorV3", `line 18: invalid dpiAwareness: "PerMonitorV3"`},
+		{">true</longPathAware>", ">1</longPathAware>", `line 19: longPathAware must be true or false, got "1"`},
+	} {
+		s := strings.Replace(validManifest, tc.Old, tc.New, 1)
+		err := Validate([]byte(s))
+		if err == nil {
+			t.Fatalf("expected failure for replacing %s with %s, got no error", tc.Old, tc.New)
+		} else if !strings.HasPrefix(err.Error(), tc.Error) {
+			t.Fatalf("wrong error for replacing %s with %s; expected %s, got %v", tc.Old, tc.New, tc.Error, err)
+		}
+	}
+	if err := Validate(nil); err == nil {
+		t.Fatal("expected failure for empty manifest, got no error")
+	}
+}
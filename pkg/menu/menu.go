@@ -0,0 +1,132 @@
+// Package menu provides menu(RT_MENU) resource related functionalities.
+package menu
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/pkg/errors"
+)
+
+// flags in bResInfo of MENUEX_TEMPLATE_ITEM
+const (
+	popupFlag = 0x01
+	lastFlag  = 0x80
+)
+
+// menu item types by their names
+var types = map[string]uint32{
+	"MFT_STRING":       0x0000,
+	"MFT_BITMAP":       0x0004,
+	"MFT_MENUBARBREAK": 0x0020,
+	"MFT_MENUBREAK":    0x0040,
+	"MFT_OWNERDRAW":    0x0100,
+	"MFT_RADIOCHECK":   0x0200,
+	"MFT_SEPARATOR":    0x0800,
+	"MFT_RIGHTORDER":   0x2000,
+	"MFT_RIGHTJUSTIFY": 0x4000,
+}
+
+// menu item states by their names
+var states = map[string]uint32{
+	"MFS_ENABLED":   0x0000,
+	"MFS_UNCHECKED": 0x0000,
+	"MFS_UNHILITE":  0x0000,
+	"MFS_GRAYED":    0x0003,
+	"MFS_DISABLED":  0x0003,
+	"MFS_CHECKED":   0x0008,
+	"MFS_HILITE":    0x0080,
+	"MFS_DEFAULT":   0x1000,
+}
+
+// MENUEX_TEMPLATE_HEADER
+type header struct {
+	Version uint16
+	Offset  uint16
+	HelpID  uint32
+}
+
+// MENUEX_TEMPLATE_ITEM without variable-length fields
+type itemHeader struct {
+	Type    uint32
+	State   uint32
+	ID      uint32
+	ResInfo uint16
+}
+
+// Menu is an extended menu template.
+type Menu struct {
+	Items []*Item
+}
+
+// Item is a menu item. An item that has sub items is a popup item,
+// which opens a submenu.
+type Item struct {
+	Type  uint32
+	State uint32
+	ID    uint32
+	Text  string
+	Items []*Item
+}
+
+// WriteTo writes the menu to w in MENUEX_TEMPLATE format.
+func (m *Menu) WriteTo(w io.Writer) (int64, error) {
+	buf := new(bytes.Buffer)
+	common.BinaryWriteTo(buf, &header{
+		Version: 1,
+		Offset:  4, // items follow HelpID
+	})
+	writeItems(buf, m.Items)
+	return buf.WriteTo(w)
+}
+
+func writeItems(buf *bytes.Buffer, items []*Item) {
+	for i, item := range items {
+		var resInfo uint16
+		if i == len(items)-1 {
+			resInfo |= lastFlag
+		}
+		if len(item.Items) > 0 {
+			resInfo |= popupFlag
+		}
+		common.BinaryWriteTo(buf, &itemHeader{
+			Type:    item.Type,
+			State:   item.State,
+			ID:      item.ID,
+			ResInfo: resInfo,
+		})
+		common.BinaryWriteTo(buf, utf16.Encode([]rune(item.Text+"\x00")))
+		common.WritePaddingTo(buf, (4-buf.Len()%4)%4) // items are aligned to 32 bits
+		if len(item.Items) > 0 {
+			common.BinaryWriteTo(buf, uint32(0)) // help id of submenu
+			writeItems(buf, item.Items)
+		}
+	}
+}
+
+// ParseFlags parses menu item type and state names and numbers combined
+// with "|", like "MFT_RADIOCHECK | MFS_CHECKED". Names are
+// case-insensitive, and numbers are treated as types.
+func ParseFlags(s string) (typ, state uint32, err error) {
+	for _, part := range strings.Split(s, "|") {
+		part = strings.TrimSpace(part)
+		name := strings.ToUpper(part)
+		if v, ok := types[name]; ok {
+			typ |= v
+			continue
+		} else if v, ok := states[name]; ok {
+			state |= v
+			continue
+		}
+		v, err := strconv.ParseUint(part, 0, 32)
+		if err != nil {
+			return 0, 0, errors.Errorf("unknown flag: %q", part)
+		}
+		typ |= uint32(v)
+	}
+	return typ, state, nil
+}
@@ -0,0 +1,76 @@
+package menu
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	m := &Menu{
+		Items: []*Item{
+			{Text: "&File", Items: []*Item{
+				{ID: 100, Text: "&Open"},
+				{Type: 0x800},
+				{ID: 101, Text: "E&xit"},
+			}},
+			{ID: 200, Text: "A"},
+		},
+	}
+	b := new(bytes.Buffer)
+	n, err := m.WriteTo(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := b.Bytes()
+	if n != int64(len(data)) {
+		t.Fatalf("wrong written length; expected %d, got %d", len(data), n)
+	}
+	// header(8), "&File"(14+12+2 padding+4 help id),
+	// "&Open"(14+12+2), separator(14+2), "E&xit"(14+12+2), "A"(14+4+2)
+	if len(data) != 8+32+28+16+28+20 {
+		t.Fatalf("wrong data length; expected %d, got %d", 8+32+28+16+28+20, len(data))
+	}
+	if ver, off := binary.LittleEndian.Uint16(data), binary.LittleEndian.Uint16(data[2:]); ver != 1 || off != 4 {
+		t.Fatalf("wrong version and offset; expected (1, 4), got (%d, %d)", ver, off)
+	}
+	for _, tc := range []struct {
+		Offset  int
+		ResInfo uint16
+	}{
+		{8, popupFlag},
+		{8 + 32, 0},
+		{8 + 32 + 28 + 16, lastFlag},
+		{8 + 32 + 28 + 16 + 28, lastFlag},
+	} {
+		if v := binary.LittleEndian.Uint16(data[tc.Offset+12:]); v != tc.ResInfo {
+			t.Fatalf("wrong resource info of item at %d; expected %#x, got %#x", tc.Offset, tc.ResInfo, v)
+		}
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	for _, tc := range []struct {
+		Flags      string
+		Type       uint32
+		State      uint32
+		ShouldFail bool
+	}{
+		{Flags: "MFT_RADIOCHECK | mfs_checked", Type: 0x200, State: 0x8},
+		{Flags: "MFS_GRAYED|MFS_DEFAULT|0x20", Type: 0x20, State: 0x1003},
+		{Flags: "MF_FOO", ShouldFail: true},
+	} {
+		typ, state, err := ParseFlags(tc.Flags)
+		if tc.ShouldFail {
+			if err == nil {
+				t.Fatalf("expected failure for %q, got no error", tc.Flags)
+			}
+			continue
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if typ != tc.Type || state != tc.State {
+			t.Fatalf("wrong flags for %q; expected (%#x, %#x), got (%#x, %#x)", tc.Flags, tc.Type, tc.State, typ, state)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package msgtable
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ParseMC parses a message text file of message compiler(mc.exe) and
+// returns message tables by their language ids.
+//
+// Only a subset of the format is supported: SeverityNames,
+// FacilityNames and LanguageNames in the header, and MessageId,
+// Severity, Facility, SymbolicName and Language in message
+// definitions. Other header keywords are ignored, and so are comment
+// lines that start with a semicolon.
+func ParseMC(r io.Reader) (map[uint16]*Table, error) {
+	p := &mcParser{
+		severities: make(map[string]int),
+		facilities: map[string]int{
+			"system":      0x0ff,
+			"application": 0xfff,
+		},
+		languages: map[string]uint16{
+			"english": 0x0409,
+		},
+		lastCodes: make(map[int]int),
+		tables:    make(map[uint16]*Table),
+	}
+	for name, sev := range severityNames {
+		p.severities[name] = sev
+	}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		p.line++
+		if err := p.parseLine(strings.TrimRight(s.Text(), "\r")); err != nil {
+			return nil, errors.Wrapf(err, "line %d", p.line)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "failed to read data")
+	}
+	if p.text != nil {
+		return nil, errors.Errorf("line %d: message text is not terminated by a single period line", p.line)
+	} else if p.list != "" {
+		return nil, errors.Errorf("line %d: %s is not closed", p.line, p.list)
+	} else if p.defined && !p.hasText {
+		return nil, errors.Errorf("line %d: message has no text", p.line)
+	}
+	return p.tables, nil
+}
+
+type mcParser struct {
+	line       int
+	severities map[string]int
+	facilities map[string]int
+	languages  map[string]uint16
+
+	// current name list that spans multiple lines
+	list      string
+	listValue string
+
+	// current message
+	defined   bool
+	hasText   bool
+	severity  int
+	facility  int
+	code      int
+	lastCodes map[int]int // last message code by facility
+	language  uint16
+	text      []string
+
+	tables map[uint16]*Table
+}
+
+func (p *mcParser) parseLine(line string) error {
+	if p.text != nil {
+		if line == "." {
+			return p.endText()
+		}
+		p.text = append(p.text, line)
+		return nil
+	}
+	if p.list != "" {
+		p.listValue += " " + line
+		if strings.Contains(line, ")") {
+			return p.endList()
+		}
+		return nil
+	}
+
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, ";") {
+		return nil
+	}
+	i := strings.Index(trimmed, "=")
+	if i < 0 {
+		return errors.Errorf("unexpected line: %q", trimmed)
+	}
+	key, value := strings.ToLower(strings.TrimSpace(trimmed[:i])), strings.TrimSpace(trimmed[i+1:])
+
+	switch key {
+	case "severitynames", "facilitynames", "languagenames":
+		if p.defined {
+			return errors.Errorf("%s must be in the header", trimmed[:i])
+		}
+		if !strings.HasPrefix(value, "(") {
+			return errors.Errorf("%s must be a list in parentheses", trimmed[:i])
+		}
+		p.list, p.listValue = key, value
+		if strings.Contains(value, ")") {
+			return p.endList()
+		}
+	case "messageid":
+		if p.defined && !p.hasText {
+			return errors.New("previous message has no text")
+		}
+		p.defined, p.hasText = true, false
+		return p.setCode(value)
+	case "severity":
+		if !p.defined {
+			return errors.New("Severity must follow MessageId")
+		}
+		sev, ok := p.severities[strings.ToLower(value)]
+		if !ok {
+			return errors.Errorf("undefined severity: %q", value)
+		}
+		p.severity = sev
+	case "facility":
+		if !p.defined {
+			return errors.New("Facility must follow MessageId")
+		}
+		fac, ok := p.facilities[strings.ToLower(value)]
+		if !ok {
+			return errors.Errorf("undefined facility: %q", value)
+		}
+		p.facility = fac
+	case "symbolicname", "outputbase", "messageidtypedef":
+	case "language":
+		if !p.defined {
+			return errors.New("Language must follow MessageId")
+		}
+		lang, ok := p.languages[strings.ToLower(value)]
+		if !ok {
+			return errors.Errorf("undefined language: %q", value)
+		}
+		p.language = lang
+		p.text = []string{}
+	default:
+		return errors.Errorf("unsupported keyword: %q", trimmed[:i])
+	}
+	return nil
+}
+
+// setCode sets current message code from value of MessageId, which is
+// either empty, a number, or a number prefixed with plus sign that is
+// relative to the last code of current facility.
+func (p *mcParser) setCode(value string) error {
+	last := p.lastCodes[p.facility]
+	switch {
+	case value == "":
+		p.code = last + 1
+	case strings.HasPrefix(value, "+"):
+		n, err := strconv.ParseUint(value[1:], 0, 16)
+		if err != nil {
+			return errors.Wrapf(err, "invalid message id: %q", value)
+		}
+		p.code = last + int(n)
+	default:
+		n, err := strconv.ParseUint(value, 0, 16)
+		if err != nil {
+			return errors.Wrapf(err, "invalid message id: %q", value)
+		}
+		p.code = int(n)
+	}
+	if p.code > 0xffff {
+		return errors.Errorf("message id is too large: %d", p.code)
+	}
+	return nil
+}
+
+// endText adds current message text to the table of its language.
+func (p *mcParser) endText() error {
+	p.lastCodes[p.facility] = p.code
+	id := MessageID(p.severity, p.facility, p.code)
+	t, ok := p.tables[p.language]
+	if !ok {
+		t = New()
+		p.tables[p.language] = t
+	}
+	if _, ok := t.Message(id); ok {
+		return errors.Errorf("duplicate message %#08x in language %#04x", id, p.language)
+	}
+	var text string
+	for _, line := range p.text {
+		text += line + "\r\n"
+	}
+	t.SetMessage(id, text)
+	p.text = nil
+	p.hasText = true
+	return nil
+}
+
+// endList parses current name list, which is like
+// "(name=value:symbol ...)". The symbol part is ignored.
+func (p *mcParser) endList() error {
+	key, value := p.list, p.listValue
+	p.list, p.listValue = "", ""
+	end := strings.Index(value, ")")
+	if strings.TrimSpace(value[end+1:]) != "" {
+		return errors.Errorf("unexpected text after list: %q", value[end+1:])
+	}
+	for _, item := range strings.Fields(value[1:end]) {
+		parts := strings.SplitN(item, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return errors.Errorf("invalid list item: %q", item)
+		}
+		name := strings.ToLower(parts[0])
+		n, err := strconv.ParseUint(strings.SplitN(parts[1], ":", 2)[0], 0, 16)
+		if err != nil {
+			return errors.Wrapf(err, "invalid value of %q", parts[0])
+		}
+		switch key {
+		case "severitynames":
+			if n > 0x3 {
+				return errors.Errorf("severity %q is out of range: %#x", parts[0], n)
+			}
+			p.severities[name] = int(n)
+		case "facilitynames":
+			if n > 0xfff {
+				return errors.Errorf("facility %q is out of range: %#x", parts[0], n)
+			}
+			p.facilities[name] = int(n)
+		case "languagenames":
+			p.languages[name] = uint16(n)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package msgtable
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseMC(t *testing.T) {
+	f, err := os.Open(filepath.Join("..", "..", "testdata", "messages.mc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tables, err := ParseMC(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("wrong number of tables; expected 2, got %d", len(tables))
+	}
+	for _, tc := range []struct {
+		Language uint16
+		ID       uint32
+		Text     string
+	}{
+		{0x0409, 0x41000001, "Service %1 started.\r\n"},
+		{0x0412, 0x41000001, "서비스 %1 시작됨.\r\n"},
+		{0x0409, 0xc1000002, "Service %1 failed:\r\n%2\r\n"},
+	} {
+		if text, ok := tables[tc.Language].Message(tc.ID); !ok || text != tc.Text {
+			t.Fatalf("wrong message %#08x in language %#04x; expected %q, got %q", tc.ID, tc.Language, tc.Text, text)
+		}
+	}
+	if n := len(tables[0x0412].IDs()); n != 1 {
+		t.Fatalf("wrong number of korean messages; expected 1, got %d", n)
+	}
+}
+
+func TestParseMC_invalidData(t *testing.T) {
+	for i, tc := range []string{
+		"Foo=bar",
+		"Severity=Error",
+		"MessageId=1\nLanguage=German\nfoo\n.",
+		"MessageId=1\nSeverity=Fatal\nLanguage=English\nfoo\n.",
+		"MessageId=1\nLanguage=English\nfoo",
+		"MessageId=1\nLanguage=English\nfoo\n.\nMessageId=1\nLanguage=English\nbar\n.",
+		"MessageId=1\nMessageId=2\nLanguage=English\nfoo\n.",
+		"MessageId=0x10000\nLanguage=English\nfoo\n.",
+		"LanguageNames=(German=0x407:MSG00407",
+		"SeverityNames=(Fatal=0x4)",
+		"MessageId=1\nLanguage=English\nfoo\n.\nLanguageNames=(German=0x407)",
+	} {
+		if _, err := ParseMC(strings.NewReader(tc)); err == nil {
+			t.Fatalf("expected failure for case #%d, got no error", i)
+		}
+	}
+}
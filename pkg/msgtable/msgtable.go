@@ -0,0 +1,185 @@
+// Package msgtable provides message table(RT_MESSAGETABLE) resource
+// related functionalities.
+package msgtable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/pkg/errors"
+)
+
+// predefined severities
+const (
+	SeveritySuccess       = 0x0
+	SeverityInformational = 0x1
+	SeverityWarning       = 0x2
+	SeverityError         = 0x3
+)
+
+var severityNames = map[string]int{
+	"success":       SeveritySuccess,
+	"informational": SeverityInformational,
+	"warning":       SeverityWarning,
+	"error":         SeverityError,
+}
+
+// Severity returns the predefined severity of name, which is one of
+// Success, Informational, Warning and Error. Name is case-insensitive.
+func Severity(name string) (int, bool) {
+	sev, ok := severityNames[strings.ToLower(name)]
+	return sev, ok
+}
+
+// MessageID returns a message id composed of severity(0 to 3),
+// facility(0 to 0xfff) and code(0 to 0xffff).
+func MessageID(severity, facility, code int) uint32 {
+	return uint32(severity&0x3)<<30 | uint32(facility&0xfff)<<16 | uint32(code&0xffff)
+}
+
+// flags in MESSAGE_RESOURCE_ENTRY
+const unicodeFlag = 0x1
+
+// MESSAGE_RESOURCE_BLOCK
+type block struct {
+	LowID           uint32
+	HighID          uint32
+	OffsetToEntries uint32
+}
+
+// Table is a set of messages identified by message ids.
+type Table struct {
+	messages map[uint32]string
+}
+
+// New returns an empty message table.
+func New() *Table {
+	return &Table{
+		messages: make(map[uint32]string),
+	}
+}
+
+// SetMessage sets the message text of id to text.
+func (t *Table) SetMessage(id uint32, text string) {
+	t.messages[id] = text
+}
+
+// Message returns the message text of id.
+func (t *Table) Message(id uint32) (string, bool) {
+	text, ok := t.messages[id]
+	return text, ok
+}
+
+// IDs returns message ids in ascending order.
+func (t *Table) IDs() []uint32 {
+	var ids []uint32
+	for id := range t.messages {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// WriteTo writes the table to w in MESSAGE_RESOURCE_DATA format.
+// Consecutive message ids are grouped into a block, and message texts
+// are stored in UTF-16.
+func (t *Table) WriteTo(w io.Writer) (int64, error) {
+	ids := t.IDs()
+	var blocks []*block
+	for i, id := range ids {
+		if i > 0 && ids[i-1]+1 == id {
+			blocks[len(blocks)-1].HighID = id
+			continue
+		}
+		blocks = append(blocks, &block{LowID: id, HighID: id})
+	}
+
+	entries := new(bytes.Buffer)
+	offset := 4 + binary.Size(&block{})*len(blocks)
+	i := 0
+	for _, b := range blocks {
+		b.OffsetToEntries = uint32(offset + entries.Len())
+		for ; i < len(ids) && ids[i] <= b.HighID; i++ {
+			text := utf16.Encode([]rune(t.messages[ids[i]] + "\x00"))
+			length := 4 + len(text)*2
+			length += (4 - length%4) % 4 // entries are aligned to 32 bits
+			if length > 0xffff {
+				return 0, errors.Errorf("message %#08x is too long", ids[i])
+			}
+			common.BinaryWriteTo(entries, []uint16{uint16(length), unicodeFlag})
+			common.BinaryWriteTo(entries, text)
+			common.WritePaddingTo(entries, length-4-len(text)*2)
+		}
+	}
+
+	var written int64
+	n, err := common.BinaryWriteTo(w, uint32(len(blocks)))
+	if err != nil {
+		return written, err
+	}
+	written += n
+	for _, b := range blocks {
+		n, err := common.BinaryWriteTo(w, b)
+		if err != nil {
+			return written, err
+		}
+		written += n
+	}
+	n, err = entries.WriteTo(w)
+	written += n
+	return written, err
+}
+
+// Decode decodes MESSAGE_RESOURCE_DATA in b.
+func Decode(b []byte) (*Table, error) {
+	if len(b) < 4 {
+		return nil, errors.New("number of blocks is truncated")
+	}
+	count := binary.LittleEndian.Uint32(b)
+	if int64(count)*int64(binary.Size(&block{})) > int64(len(b)-4) {
+		return nil, errors.New("blocks are truncated")
+	}
+	blocks := make([]block, count)
+	if err := binary.Read(bytes.NewReader(b[4:]), binary.LittleEndian, blocks); err != nil {
+		return nil, errors.Wrap(err, "failed to read blocks")
+	}
+
+	t := New()
+	for i, blk := range blocks {
+		if blk.LowID > blk.HighID {
+			return nil, errors.Errorf("block #%d has invalid id range %#08x-%#08x", i, blk.LowID, blk.HighID)
+		} else if int64(blk.HighID-blk.LowID) >= int64(len(b)/4) {
+			return nil, errors.Errorf("block #%d has too many entries", i)
+		}
+		offset := int64(blk.OffsetToEntries)
+		for id := int64(blk.LowID); id <= int64(blk.HighID); id++ {
+			if offset+4 > int64(len(b)) {
+				return nil, errors.Errorf("message %#08x is truncated", id)
+			}
+			length := int64(binary.LittleEndian.Uint16(b[offset:]))
+			flags := binary.LittleEndian.Uint16(b[offset+2:])
+			if length < 4 || offset+length > int64(len(b)) {
+				return nil, errors.Errorf("message %#08x has invalid length: %d", id, length)
+			}
+			text := b[offset+4 : offset+length]
+			if flags&unicodeFlag != 0 {
+				u := make([]uint16, len(text)/2)
+				for j := range u {
+					u[j] = binary.LittleEndian.Uint16(text[j*2:])
+				}
+				t.SetMessage(uint32(id), strings.TrimRight(string(utf16.Decode(u)), "\x00"))
+			} else {
+				t.SetMessage(uint32(id), strings.TrimRight(string(text), "\x00"))
+			}
+			offset += length
+		}
+	}
+	return t, nil
+}
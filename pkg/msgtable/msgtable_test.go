@@ -0,0 +1,64 @@
+package msgtable
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	tbl := New()
+	for id, text := range map[uint32]string{
+		1:          "foo\r\n",
+		2:          "bar",
+		3:          "",
+		0xc0010005: "한글\r\n",
+	} {
+		tbl.SetMessage(id, text)
+	}
+
+	b := new(bytes.Buffer)
+	n, err := tbl.WriteTo(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(b.Len()) {
+		t.Fatalf("wrong written length; expected %d, got %d", b.Len(), n)
+	}
+	if count := b.Bytes()[0]; count != 2 {
+		t.Fatalf("wrong number of blocks; expected 2, got %d", count)
+	}
+
+	tbl2, err := Decode(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tbl2.IDs()) != len(tbl.IDs()) {
+		t.Fatalf("wrong number of messages; expected %d, got %d", len(tbl.IDs()), len(tbl2.IDs()))
+	}
+	for _, id := range tbl.IDs() {
+		text, _ := tbl.Message(id)
+		if text2, ok := tbl2.Message(id); !ok || text2 != text {
+			t.Fatalf("wrong message %#08x; expected %q, got %q", id, text, text2)
+		}
+	}
+}
+
+func TestDecode_invalidData(t *testing.T) {
+	for i, tc := range [][]byte{
+		{},
+		{0x01, 0x00, 0x00, 0x00},
+		{0x01, 0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00},                         // bad id range
+		{0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00},                         // no entry
+		{0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00, 0x08, 0x00, 0x01, 0x00}, // truncated entry
+	} {
+		if _, err := Decode(tc); err == nil {
+			t.Fatalf("expected failure for case #%d, got no error", i)
+		}
+	}
+}
+
+func TestMessageID(t *testing.T) {
+	if id := MessageID(SeverityError, 0x100, 1); id != 0xc1000001 {
+		t.Fatalf("wrong message id; expected 0xc1000001, got %#08x", id)
+	}
+}
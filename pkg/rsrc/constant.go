@@ -2,12 +2,59 @@ package rsrc
 
 // Common resource types.
 const (
-	IconResource        = 3
-	IconGroupResource   = 14
-	VersionInfoResource = 16
-	ManifestResource    = 24
+	CursorResource         = 1
+	BitmapResource         = 2
+	IconResource           = 3
+	MenuResource           = 4
+	DialogResource         = 5
+	StringResource         = 6
+	FontDirResource        = 7
+	FontResource           = 8
+	AcceleratorResource    = 9
+	RCDataResource         = 10
+	MessageTableResource   = 11
+	CursorGroupResource    = 12
+	IconGroupResource      = 14
+	VersionInfoResource    = 16
+	DlgIncludeResource     = 17
+	PlugPlayResource       = 19
+	VXDResource            = 20
+	AnimatedCursorResource = 21
+	AnimatedIconResource   = 22
+	HTMLResource           = 23
+	ManifestResource       = 24
 )
 
 const (
 	enUSLanguage = 0x0409
 )
+
+var typeNames = map[int]string{
+	CursorResource:         "RT_CURSOR",
+	BitmapResource:         "RT_BITMAP",
+	IconResource:           "RT_ICON",
+	MenuResource:           "RT_MENU",
+	DialogResource:         "RT_DIALOG",
+	StringResource:         "RT_STRING",
+	FontDirResource:        "RT_FONTDIR",
+	FontResource:           "RT_FONT",
+	AcceleratorResource:    "RT_ACCELERATOR",
+	RCDataResource:         "RT_RCDATA",
+	MessageTableResource:   "RT_MESSAGETABLE",
+	CursorGroupResource:    "RT_GROUP_CURSOR",
+	IconGroupResource:      "RT_GROUP_ICON",
+	VersionInfoResource:    "RT_VERSION",
+	DlgIncludeResource:     "RT_DLGINCLUDE",
+	PlugPlayResource:       "RT_PLUGPLAY",
+	VXDResource:            "RT_VXD",
+	AnimatedCursorResource: "RT_ANICURSOR",
+	AnimatedIconResource:   "RT_ANIICON",
+	HTMLResource:           "RT_HTML",
+	ManifestResource:       "RT_MANIFEST",
+}
+
+// TypeName returns the name of a predefined resource type, such as
+// "RT_ICON". It returns an empty string for unknown types.
+func TypeName(typ int) string {
+	return typeNames[typ]
+}
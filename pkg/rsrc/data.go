@@ -1,6 +1,11 @@
 package rsrc
 
-import "github.com/hallazzang/syso/pkg/common"
+import (
+	"crypto/sha256"
+
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/pkg/errors"
+)
 
 type rawDataEntry struct {
 	DataRVA  uint32
@@ -11,12 +16,55 @@ type rawDataEntry struct {
 
 // DataEntry is a header for Data.
 type DataEntry struct {
-	offset uint32
-	data   *Data
+	offset   uint32
+	codepage uint32
+	data     *Data
+}
+
+// Codepage returns the codepage used to decode code point values
+// within the resource data.
+func (e *DataEntry) Codepage() uint32 {
+	return e.codepage
+}
+
+// SetCodepage sets the codepage of the resource data.
+func (e *DataEntry) SetCodepage(codepage uint32) {
+	e.codepage = codepage
+}
+
+// Data returns the resource data.
+func (e *DataEntry) Data() *Data {
+	return e.data
 }
 
 // Data represents actual binary resource data in .rsrc section.
 type Data struct {
 	offset uint32
+	digest *[sha256.Size]byte
 	common.Blob
 }
+
+// Bytes returns the resource data without consuming it. It fails if
+// the underlying blob doesn't provide its content, which is the case
+// for blobs other than the ones created by common package.
+func (d *Data) Bytes() ([]byte, error) {
+	b, ok := d.Blob.(interface{ Bytes() []byte })
+	if !ok {
+		return nil, errors.Errorf("data of type %T is not accessible", d.Blob)
+	}
+	return b.Bytes(), nil
+}
+
+// sum returns SHA-256 checksum of the resource data, which is cached
+// after the first call.
+func (d *Data) sum() ([sha256.Size]byte, error) {
+	if d.digest == nil {
+		b, err := d.Bytes()
+		if err != nil {
+			return [sha256.Size]byte{}, err
+		}
+		sum := sha256.Sum256(b)
+		d.digest = &sum
+	}
+	return *d.digest, nil
+}
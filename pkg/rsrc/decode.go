@@ -0,0 +1,158 @@
+package rsrc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/common"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	coff.RegisterSectionDecoder(".rsrc", func(data []byte, _ []coff.Relocation) (coff.Section, error) {
+		// data entries in an object file hold offsets relative to the
+		// section, which are later relocated by the linker.
+		return Decode(data, 0)
+	})
+}
+
+// maxDirectoryDepth limits resource directory nesting level.
+// Normal resource tree has only three levels: type, name and language.
+const maxDirectoryDepth = 8
+
+type decoder struct {
+	data    []byte
+	base    uint32
+	visited map[uint32]bool // offsets of decoded directories and data entries
+}
+
+// Decode decodes raw .rsrc section data into a Section. base is the
+// relative virtual address of the section, against which data entries'
+// RVAs are resolved: it is 0 for object files, and the section's
+// VirtualAddress for PE images.
+func Decode(data []byte, base uint32) (*Section, error) {
+	d := &decoder{
+		data:    data,
+		base:    base,
+		visited: make(map[uint32]bool),
+	}
+	s := New()
+	if err := d.decodeDirectory(s.rootDir, 0, 0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (d *decoder) read(offset uint32, v interface{}) error {
+	size := binary.Size(v)
+	if int64(offset)+int64(size) > int64(len(d.data)) {
+		return errors.Errorf("offset %#x is out of range", offset)
+	}
+	return binary.Read(bytes.NewReader(d.data[offset:int(offset)+size]), binary.LittleEndian, v)
+}
+
+func (d *decoder) decodeDirectory(dir *Directory, offset uint32, depth int) error {
+	if depth >= maxDirectoryDepth {
+		return errors.New("resource directory is nested too deeply")
+	}
+	// sharing directories between entries would make decoding take
+	// exponential time, so every directory is decoded once.
+	if err := d.visit(offset); err != nil {
+		return errors.Wrap(err, "failed to read resource directory")
+	}
+
+	var rd rawDirectory
+	if err := d.read(offset, &rd); err != nil {
+		return errors.Wrap(err, "failed to read resource directory")
+	}
+	dir.characteristics = rd.Characteristics
+
+	offset += uint32(binary.Size(&rd))
+	for i := 0; i < int(rd.NumberOfNameEntries)+int(rd.NumberOfIDEntries); i++ {
+		var re rawDirectoryEntry
+		if err := d.read(offset, &re); err != nil {
+			return errors.Wrapf(err, "failed to read resource directory entry #%d", i)
+		}
+		offset += uint32(binary.Size(&re))
+
+		var name *string
+		var id *int
+		if re.NameOffsetOrIntegerID&0x80000000 != 0 {
+			s, err := d.readString(re.NameOffsetOrIntegerID &^ 0x80000000)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read resource directory entry #%d's name", i)
+			}
+			name = &s
+		} else {
+			n := int(re.NameOffsetOrIntegerID)
+			id = &n
+		}
+
+		if re.DataEntryOffsetOrSubdirectoryOffset&0x80000000 != 0 {
+			subdir, err := dir.addSubdirectory(name, id, 0)
+			if err != nil {
+				return errors.Wrapf(err, "failed to add resource directory entry #%d", i)
+			}
+			if err := d.decodeDirectory(subdir, re.DataEntryOffsetOrSubdirectoryOffset&^0x80000000, depth+1); err != nil {
+				return err
+			}
+		} else {
+			blob, codepage, err := d.readData(re.DataEntryOffsetOrSubdirectoryOffset)
+			if err != nil {
+				return errors.Wrapf(err, "failed to read resource directory entry #%d's data", i)
+			}
+			e, err := dir.addData(name, id, blob)
+			if err != nil {
+				return errors.Wrapf(err, "failed to add resource directory entry #%d", i)
+			}
+			e.codepage = codepage
+		}
+	}
+
+	return nil
+}
+
+// visit marks a directory or data entry at offset as decoded, and
+// returns an error if it is already decoded.
+func (d *decoder) visit(offset uint32) error {
+	if d.visited[offset] {
+		return errors.Errorf("offset %#x is referenced more than once", offset)
+	}
+	d.visited[offset] = true
+	return nil
+}
+
+func (d *decoder) readString(offset uint32) (string, error) {
+	var length uint16
+	if err := d.read(offset, &length); err != nil {
+		return "", errors.Wrap(err, "failed to read string length")
+	}
+	u := make([]uint16, length)
+	if err := d.read(offset+2, u); err != nil {
+		return "", errors.Wrap(err, "failed to read string")
+	}
+	return string(utf16.Decode(u)), nil
+}
+
+func (d *decoder) readData(offset uint32) (common.Blob, uint32, error) {
+	if err := d.visit(offset); err != nil {
+		return nil, 0, err
+	}
+	var rde rawDataEntry
+	if err := d.read(offset, &rde); err != nil {
+		return nil, 0, errors.Wrap(err, "failed to read data entry")
+	}
+	if rde.DataRVA < d.base {
+		return nil, 0, errors.Errorf("data rva %#x is out of section", rde.DataRVA)
+	}
+	start := int64(rde.DataRVA - d.base)
+	end := start + int64(rde.Size)
+	if end > int64(len(d.data)) {
+		return nil, 0, errors.Errorf("data at rva %#x is out of section", rde.DataRVA)
+	}
+	b := make([]byte, rde.Size)
+	copy(b, d.data[start:end])
+	return common.NewBlobFromBytes(b), rde.Codepage, nil
+}
@@ -0,0 +1,99 @@
+package rsrc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/hallazzang/syso/pkg/common"
+)
+
+func TestDecode(t *testing.T) {
+	for _, base := range []uint32{0, 0x1000} {
+		r := New()
+		if err := r.AddResourceByID(ManifestResource, 1, common.NewBlobFromBytes([]byte("manifest"))); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.AddResourceByName(VersionInfoResource, "VERSION", common.NewBlobFromBytes([]byte("version"))); err != nil {
+			t.Fatal(err)
+		}
+		b := new(bytes.Buffer)
+		if _, err := r.WriteTo(b); err != nil {
+			t.Fatal(err)
+		}
+		data := b.Bytes()
+		for _, rel := range r.Relocations() { // simulate linker
+			va := rel.VirtualAddress()
+			binary.LittleEndian.PutUint32(data[va:], binary.LittleEndian.Uint32(data[va:])+base)
+		}
+
+		r2, err := Decode(data, base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r2.ResourceIDExists(1) || !r2.ResourceNameExists("VERSION") {
+			t.Fatal("resource not found")
+		}
+		types := r2.Root().Entries()
+		if len(types) != 2 {
+			t.Fatalf("wrong type entries length; expected 2, got %d", len(types))
+		}
+		for i, tc := range []struct {
+			Type int
+			Data string
+		}{
+			{VersionInfoResource, "version"},
+			{ManifestResource, "manifest"},
+		} {
+			typ, ok := types[i].ID()
+			if !ok || typ != tc.Type {
+				t.Fatalf("wrong type; expected %d, got %d", tc.Type, typ)
+			}
+			lang := types[i].Subdirectory().Entries()[0].Subdirectory().Entries()[0]
+			if id, _ := lang.ID(); id != enUSLanguage {
+				t.Fatalf("wrong language; expected %#04x, got %#04x", enUSLanguage, id)
+			}
+			d, err := lang.DataEntry().Data().Bytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(d) != tc.Data {
+				t.Fatalf("wrong data; expected %q, got %q", tc.Data, d)
+			}
+		}
+	}
+}
+
+func TestDecode_invalidData(t *testing.T) {
+	for _, tc := range [][]byte{
+		{},
+		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0},
+		// directory entry pointing to itself
+		{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 0, 0x80},
+	} {
+		if _, err := Decode(tc, 0); err == nil {
+			t.Fatal("expected failure, got no error")
+		}
+	}
+}
+
+func TestDecode_sharedDirectories(t *testing.T) {
+	// every entry of a level points to the same directory of the next
+	// level, which makes a tree of 10^7 entries if decoded naively.
+	const levels, entries = 7, 10
+	var buf bytes.Buffer
+	dirSize := binary.Size(&rawDirectory{}) + entries*binary.Size(&rawDirectoryEntry{})
+	for level := 0; level < levels; level++ {
+		binary.Write(&buf, binary.LittleEndian, &rawDirectory{NumberOfIDEntries: entries})
+		for i := 0; i < entries; i++ {
+			binary.Write(&buf, binary.LittleEndian, &rawDirectoryEntry{
+				NameOffsetOrIntegerID:               uint32(i + 1),
+				DataEntryOffsetOrSubdirectoryOffset: uint32((level+1)*dirSize) | 0x80000000,
+			})
+		}
+	}
+	binary.Write(&buf, binary.LittleEndian, &rawDirectory{})
+	if _, err := Decode(buf.Bytes(), 0); err == nil {
+		t.Fatal("expected failure, got no error")
+	}
+}
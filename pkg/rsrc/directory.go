@@ -26,6 +26,17 @@ type Directory struct {
 	strings         map[string]*String
 }
 
+// Characteristics returns the directory's characteristics.
+func (d *Directory) Characteristics() uint32 {
+	return d.characteristics
+}
+
+// Entries returns the directory's entries, named entries first and
+// then integer id entries.
+func (d *Directory) Entries() []*DirectoryEntry {
+	return d.entries()
+}
+
 func (d *Directory) addString(s string) *String {
 	str, ok := d.strings[s]
 	if ok {
@@ -54,6 +65,23 @@ func (d *Directory) addSubdirectory(name *string, id *int, characteristics uint3
 	return e.subdirectory, nil
 }
 
+// subdirectory returns a subdirectory identified by name or id, or nil
+// if not found.
+func (d *Directory) subdirectory(name *string, id *int) *Directory {
+	for _, e := range d.entries() {
+		if name != nil {
+			if e.name != nil && e.name.string == *name {
+				return e.subdirectory
+			}
+		} else {
+			if e.id != nil && *e.id == *id {
+				return e.subdirectory
+			}
+		}
+	}
+	return nil
+}
+
 func (d *Directory) addDirectoryEntry(name *string, id *int, characteristics *uint32, blob common.Blob) (*DirectoryEntry, error) {
 	for _, e := range d.entries() {
 		if name != nil {
@@ -162,6 +190,36 @@ type DirectoryEntry struct {
 	subdirectory *Directory
 }
 
+// ID returns the entry's integer id. ok is false if the entry is
+// identified by a name.
+func (e *DirectoryEntry) ID() (id int, ok bool) {
+	if e.id == nil {
+		return 0, false
+	}
+	return *e.id, true
+}
+
+// Name returns the entry's name. ok is false if the entry is
+// identified by an integer id.
+func (e *DirectoryEntry) Name() (name string, ok bool) {
+	if e.name == nil {
+		return "", false
+	}
+	return e.name.string, true
+}
+
+// Subdirectory returns the entry's subdirectory, or nil if the entry
+// points to a data entry.
+func (e *DirectoryEntry) Subdirectory() *Directory {
+	return e.subdirectory
+}
+
+// DataEntry returns the entry's data entry, or nil if the entry points
+// to a subdirectory.
+func (e *DirectoryEntry) DataEntry() *DataEntry {
+	return e.dataEntry
+}
+
 // String holds a resource string.
 type String struct {
 	offset uint32
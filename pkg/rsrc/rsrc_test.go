@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/common"
 	"github.com/hallazzang/syso/pkg/ico"
 )
 
@@ -33,3 +35,203 @@ func TestAddIconByName(t *testing.T) {
 	}
 	t.Logf("wrote %d bytes", n)
 }
+
+func TestParseCOFF(t *testing.T) {
+	f, err := os.Open(filepath.Join("..", "..", "testdata", "golang.ico"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	icons, err := ico.DecodeAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := New()
+	if err := r.AddResourceByName(IconResource, "ICON", icons.Images[0]); err != nil {
+		t.Fatal(err)
+	}
+	c := coff.New(coff.MachineI386)
+	if err := c.AddSection(r); err != nil {
+		t.Fatal(err)
+	}
+	b := new(bytes.Buffer)
+	if _, err := c.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+
+	c2, err := coff.Parse(bytes.NewReader(b.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := c2.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, ok := s.(*Section)
+	if !ok {
+		t.Fatalf("wrong section type; expected *rsrc.Section, got %T", s)
+	}
+	if !r2.ResourceNameExists("ICON") {
+		t.Fatal("resource not found")
+	}
+	if r2.Size() != r.Size() {
+		t.Fatalf("wrong section size; expected %d, got %d", r.Size(), r2.Size())
+	}
+}
+
+func TestAddResource_multipleLanguages(t *testing.T) {
+	r := New()
+	for _, lang := range []int{0x0409, 0x0412} {
+		if err := r.AddResource(ManifestResource, 1, lang, common.NewBlobFromBytes([]byte("foo"))); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.AddResource(ManifestResource, "NAME", lang, common.NewBlobFromBytes([]byte("foo"))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.AddResource(ManifestResource, 1, 0x0409, common.NewBlobFromBytes([]byte("foo"))); err == nil {
+		t.Fatal("expected failure for duplicate language, got no error")
+	}
+	if err := r.AddResource(ManifestResource, 1, 0x10000, common.NewBlobFromBytes([]byte("foo"))); err == nil {
+		t.Fatal("expected failure for invalid language, got no error")
+	}
+
+	b := new(bytes.Buffer)
+	if _, err := r.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+	r2, err := Decode(b.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range r2.Root().Entries()[0].Subdirectory().Entries() {
+		if n := len(e.Subdirectory().Entries()); n != 2 {
+			t.Fatalf("wrong language entries length; expected 2, got %d", n)
+		}
+	}
+}
+
+func TestAddResource_namedType(t *testing.T) {
+	r := New()
+	if err := r.AddResource("MYDATA", 1, 0x0409, common.NewBlobFromBytes([]byte("foo"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddResource("MYDATA", "BAR", 0x0409, common.NewBlobFromBytes([]byte("bar"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddResource(RCDataResource, 2, 0x0409, common.NewBlobFromBytes([]byte("baz"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddResource("", 3, 0x0409, common.NewBlobFromBytes([]byte("baz"))); err == nil {
+		t.Fatal("expected failure for empty type name, got no error")
+	}
+	if !r.ResourceIDExists(1) || !r.ResourceNameExists("BAR") {
+		t.Fatal("resource not found")
+	}
+
+	b := new(bytes.Buffer)
+	if _, err := r.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+	r2, err := Decode(b.Bytes(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := r2.Root().Entries()
+	if len(types) != 2 {
+		t.Fatalf("wrong type entries length; expected 2, got %d", len(types))
+	}
+	if name, ok := types[0].Name(); !ok || name != "MYDATA" {
+		t.Fatalf("wrong type name; expected %q, got %q", "MYDATA", name)
+	}
+	if n := len(types[0].Subdirectory().Entries()); n != 2 {
+		t.Fatalf("wrong entries length; expected 2, got %d", n)
+	}
+}
+
+func TestFindResourceID(t *testing.T) {
+	r := New()
+	for id, data := range map[int]string{1: "foo", 2: "bar", 3: "qux"} {
+		if err := r.AddResource(IconResource, id, 0x0409, common.NewBlobFromBytes([]byte(data))); err != nil {
+			t.Fatal(err)
+		}
+		if id != 3 {
+			r.MarkShareable(IconResource, id)
+		}
+	}
+	for _, tc := range []struct {
+		Type  int
+		Lang  int
+		Data  string
+		ID    int
+		Found bool
+	}{
+		{IconResource, 0x0409, "foo", 1, true},
+		{IconResource, 0x0409, "bar", 2, true},
+		{IconResource, 0x0409, "baz", 0, false},
+		{IconResource, 0x0409, "qux", 0, false}, // not shareable
+		{IconResource, 0x0412, "foo", 0, false},
+		{CursorResource, 0x0409, "foo", 0, false},
+	} {
+		id, ok := r.FindResourceID(tc.Type, tc.Lang, []byte(tc.Data))
+		if ok != tc.Found || id != tc.ID {
+			t.Fatalf("wrong result for %q; expected (%d, %v), got (%d, %v)", tc.Data, tc.ID, tc.Found, id, ok)
+		}
+	}
+}
+
+func TestAllocateResourceID(t *testing.T) {
+	r := New()
+	for _, id := range []int{1000, 1001, 1003} {
+		if err := r.AddResourceByID(IconResource, id, common.NewBlobFromBytes(nil)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.AddResourceByID(RCDataResource, 1002, common.NewBlobFromBytes(nil)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		Type, From, To int
+		ID             int
+		ShouldFail     bool
+	}{
+		{Type: IconResource, From: 1000, To: 65535, ID: 1002},
+		{Type: RCDataResource, From: 1000, To: 65535, ID: 1000},
+		{Type: IconResource, From: 1003, To: 1004, ID: 1004},
+		{Type: IconResource, From: 1000, To: 1001, ShouldFail: true},
+		{Type: IconResource, From: 0, To: 10, ShouldFail: true},
+		{Type: IconResource, From: 10, To: 65536, ShouldFail: true},
+		{Type: IconResource, From: 10, To: 9, ShouldFail: true},
+	} {
+		id, err := r.AllocateResourceID(tc.Type, tc.From, tc.To)
+		if tc.ShouldFail {
+			if err == nil {
+				t.Fatalf("expected failure for %d-%d, got no error", tc.From, tc.To)
+			}
+			continue
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if id != tc.ID {
+			t.Fatalf("wrong id; expected %d, got %d", tc.ID, id)
+		}
+	}
+}
+
+func TestAddResource_invalidID(t *testing.T) {
+	r := New()
+	for _, tc := range []struct {
+		Type, ID int
+	}{
+		{IconResource, 0},
+		{IconResource, 65536},
+		{0, 1},
+		{65536, 1},
+	} {
+		if err := r.AddResource(tc.Type, tc.ID, 0x0409, common.NewBlobFromBytes(nil)); err == nil {
+			t.Fatalf("expected failure for type %d and id %d, got no error", tc.Type, tc.ID)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package rsrc
 
 import (
+	"crypto/sha256"
 	"encoding/binary"
 	"io"
 	"unicode/utf16"
@@ -14,12 +15,16 @@ import (
 type Section struct {
 	rootDir     *Directory
 	relocations []coff.Relocation
+	shareable   map[[2]int]bool // types and ids of resources that can be shared
 }
 
 // New returns an empty .rsrc section.
 func New() *Section {
 	return &Section{
-		rootDir: &Directory{},
+		rootDir: &Directory{
+			strings: make(map[string]*String),
+		},
+		shareable: make(map[[2]int]bool),
 	}
 }
 
@@ -39,9 +44,15 @@ func (s *Section) Relocations() []coff.Relocation {
 	return s.relocations
 }
 
+// Root returns the section's root directory, whose entries are
+// resource types.
+func (s *Section) Root() *Directory {
+	return s.rootDir
+}
+
 // ResourceIDExists returns true if a resource with given integer id exists.
 func (s *Section) ResourceIDExists(id int) bool {
-	for _, e := range s.rootDir.idEntries {
+	for _, e := range s.rootDir.entries() {
 		if e.subdirectory != nil {
 			for _, e2 := range e.subdirectory.idEntries {
 				if *e2.id == id {
@@ -55,7 +66,7 @@ func (s *Section) ResourceIDExists(id int) bool {
 
 // ResourceNameExists returns true if a resource with given name exists.
 func (s *Section) ResourceNameExists(name string) bool {
-	for _, e := range s.rootDir.idEntries {
+	for _, e := range s.rootDir.entries() {
 		if e.subdirectory != nil {
 			for _, e2 := range e.subdirectory.nameEntries {
 				if e2.name.string == name {
@@ -67,53 +78,140 @@ func (s *Section) ResourceNameExists(name string) bool {
 	return false
 }
 
+// ErrIDExhausted is returned by AllocateResourceID when every id in the
+// given range is in use.
+var ErrIDExhausted = errors.New("resource id range is exhausted")
+
+// AllocateResourceID returns the smallest integer id in range [from, to]
+// that is not used by any resource of type typ. Resources of other types
+// don't matter, as resource ids are scoped by type.
+func (s *Section) AllocateResourceID(typ, from, to int) (int, error) {
+	if from < 1 || to > 0xffff || from > to {
+		return 0, errors.Errorf("invalid resource id range: %d-%d", from, to)
+	}
+	used := make(map[int]bool)
+	if subdir := s.rootDir.subdirectory(nil, &typ); subdir != nil {
+		for _, e := range subdir.idEntries {
+			used[*e.id] = true
+		}
+	}
+	for id := from; id <= to; id++ {
+		if !used[id] {
+			return id, nil
+		}
+	}
+	return 0, errors.Wrapf(ErrIDExhausted, "no free id for type %d in %d-%d", typ, from, to)
+}
+
+// MarkShareable marks resources of type typ with integer id as
+// shareable, so that FindResourceID can find them.
+func (s *Section) MarkShareable(typ, id int) {
+	s.shareable[[2]int{typ, id}] = true
+}
+
+// FindResourceID returns the integer id of a resource of type typ in
+// language lang whose data is identical to b, which makes it possible
+// to share identical resources. Only resources marked by MarkShareable
+// are searched, and ones whose data is not accessible are ignored.
+func (s *Section) FindResourceID(typ, lang int, b []byte) (int, bool) {
+	subdir := s.rootDir.subdirectory(nil, &typ)
+	if subdir == nil {
+		return 0, false
+	}
+	sum := sha256.Sum256(b)
+	for _, e := range subdir.idEntries {
+		if e.subdirectory == nil || !s.shareable[[2]int{typ, *e.id}] {
+			continue
+		}
+		for _, le := range e.subdirectory.idEntries {
+			if *le.id != lang || le.dataEntry == nil {
+				continue
+			}
+			if sum2, err := le.dataEntry.data.sum(); err == nil && sum2 == sum {
+				return *e.id, true
+			}
+		}
+	}
+	return 0, false
+}
+
 // AddResourceByID adds resource blob with arbitrary type identified by
-// an integer id into the section.
+// an integer id into the section, in en-US language.
 func (s *Section) AddResourceByID(typ, id int, blob common.Blob) error {
-	if _, err := s.addResource(typ, &id, nil, blob); err != nil {
+	if _, err := s.addResource(typ, &id, nil, enUSLanguage, blob); err != nil {
 		return err
 	}
 	return nil
 }
 
 // AddResourceByName adds resource blob with arbitrary type identified by
-// a name into the section.
+// a name into the section, in en-US language.
 func (s *Section) AddResourceByName(typ int, name string, blob common.Blob) error {
-	if _, err := s.addResource(typ, nil, &name, blob); err != nil {
+	if _, err := s.addResource(typ, nil, &name, enUSLanguage, blob); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AddResource adds resource blob identified by id in language lang
+// into the section. typ and id are either an integer id or a name;
+// a named type is a custom resource type such as "MYDATA".
+// A resource can have multiple languages, but not the same language twice.
+func (s *Section) AddResource(typ, id interface{}, lang int, blob common.Blob) error {
+	typID, typName, err := identifier(typ)
+	if err != nil {
+		return errors.Wrap(err, "invalid resource type")
+	}
+	intID, name, err := identifier(id)
+	if err != nil {
+		return err
+	}
+	if lang < 0 || lang > 0xffff {
+		return errors.Errorf("invalid language id: %d", lang)
+	}
+	if _, err := s.addTypedResource(typID, typName, intID, name, lang, blob); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *Section) addResource(typ int, id *int, name *string, blob common.Blob) (*DataEntry, error) {
-	var subdir *Directory
+func (s *Section) addResource(typ int, id *int, name *string, lang int, blob common.Blob) (*DataEntry, error) {
+	return s.addTypedResource(&typ, nil, id, name, lang, blob)
+}
+
+func (s *Section) addTypedResource(typ *int, typName *string, id *int, name *string, lang int, blob common.Blob) (*DataEntry, error) {
 	var err error
 
-	for _, e := range s.rootDir.idEntries {
-		if *e.id == typ {
-			if e.subdirectory == nil {
-				return nil, errors.New("subdirectory should exist")
-			}
-			subdir = e.subdirectory
-		}
+	if typName != nil && *typName == "" {
+		return nil, errors.New("resource type name cannot be empty")
+	}
+	if name != nil && *name == "" {
+		return nil, errors.New("resource name cannot be empty")
+	}
+	if typ != nil && (*typ < 1 || *typ > 0xffff) {
+		return nil, errors.Errorf("invalid resource type id: %d", *typ)
+	}
+	if id != nil && (*id < 1 || *id > 0xffff) {
+		return nil, errors.Errorf("invalid resource id: %d", *id)
 	}
+
+	subdir := s.rootDir.subdirectory(typName, typ)
 	if subdir == nil {
-		subdir, err = s.rootDir.addSubdirectory(nil, &typ, 0)
+		subdir, err = s.rootDir.addSubdirectory(typName, typ, 0)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to add `id` level resource directory")
 		}
 	}
 
-	if id != nil {
-		subdir, err = subdir.addSubdirectory(nil, id, 0)
+	if langDir := subdir.subdirectory(name, id); langDir != nil {
+		subdir = langDir
 	} else {
-		subdir, err = subdir.addSubdirectory(name, nil, 0)
-	}
-	if err != nil {
-		return nil, errors.Wrap(err, "failed to add `language` level subdirectory")
+		subdir, err = subdir.addSubdirectory(name, id, 0)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to add `language` level subdirectory")
+		}
 	}
 
-	lang := enUSLanguage
 	d, err := subdir.addData(nil, &lang, blob)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to add resource data")
@@ -238,8 +336,9 @@ func (s *Section) WriteTo(w io.Writer) (int64, error) {
 	if err := s.rootDir.walk(func(dir *Directory) error {
 		for i, e := range dir.dataEntries() {
 			n, err := common.BinaryWriteTo(w, &rawDataEntry{
-				DataRVA: e.data.offset,
-				Size:    uint32(e.data.Size()),
+				DataRVA:  e.data.offset,
+				Size:     uint32(e.data.Size()),
+				Codepage: e.codepage,
 			})
 			if err != nil {
 				return errors.Wrapf(err, "failed to write resource data entry #%d", i)
@@ -0,0 +1,125 @@
+// Package stringtable provides string table(RT_STRING) resource related
+// functionalities.
+package stringtable
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+// BlockSize is the number of strings in a string table block.
+const BlockSize = 16
+
+// Table is a set of strings identified by integer ids.
+type Table struct {
+	strings map[uint16]string
+}
+
+// New returns an empty string table.
+func New() *Table {
+	return &Table{
+		strings: make(map[uint16]string),
+	}
+}
+
+// SetString sets the string of id to s.
+func (t *Table) SetString(id uint16, s string) error {
+	if n := len(utf16.Encode([]rune(s))); n > 0xffff {
+		return errors.Errorf("string #%d is too long; %d characters", id, n)
+	}
+	t.strings[id] = s
+	return nil
+}
+
+// String returns the string of id.
+func (t *Table) String(id uint16) (string, bool) {
+	s, ok := t.strings[id]
+	return s, ok
+}
+
+// Blocks packs strings into blocks, sorted by block id. Each block holds
+// strings with ids from (ID-1)*16 to (ID-1)*16+15.
+func (t *Table) Blocks() []*Block {
+	var ids []int
+	blocks := make(map[int][BlockSize]string)
+	for id, s := range t.strings {
+		bid := BlockID(id)
+		ss, ok := blocks[bid]
+		if !ok {
+			ids = append(ids, bid)
+		}
+		ss[id%BlockSize] = s
+		blocks[bid] = ss
+	}
+	sort.Ints(ids)
+	var r []*Block
+	for _, bid := range ids {
+		buf := new(bytes.Buffer)
+		for _, s := range blocks[bid] {
+			u := utf16.Encode([]rune(s))
+			binary.Write(buf, binary.LittleEndian, uint16(len(u)))
+			binary.Write(buf, binary.LittleEndian, u)
+		}
+		r = append(r, &Block{
+			ID:   bid,
+			data: buf.Bytes(),
+		})
+	}
+	return r
+}
+
+// BlockID returns the id of the block that holds string of id.
+func BlockID(id uint16) int {
+	return int(id)/BlockSize + 1
+}
+
+// Block is a string table block, which is embedded as a resource.
+type Block struct {
+	ID     int
+	data   []byte
+	offset int64
+}
+
+// Read copies the block's data to p.
+func (b *Block) Read(p []byte) (int, error) {
+	n := copy(p[:], b.data[b.offset:])
+	b.offset += int64(n)
+	return n, nil
+}
+
+// Size returns the block's data size.
+func (b *Block) Size() int64 {
+	return int64(len(b.data))
+}
+
+// DecodeBlock decodes data of block id and returns its non-empty strings
+// by their string ids.
+func DecodeBlock(id int, b []byte) (map[uint16]string, error) {
+	if id < 1 || id > BlockID(0xffff) {
+		return nil, errors.Errorf("invalid block id: %d", id)
+	}
+	r := make(map[uint16]string)
+	for i := 0; i < BlockSize; i++ {
+		if len(b) < 2 {
+			return nil, errors.Errorf("string #%d's length is truncated", i)
+		}
+		n := int(binary.LittleEndian.Uint16(b))
+		b = b[2:]
+		if len(b) < n*2 {
+			return nil, errors.Errorf("string #%d is truncated", i)
+		}
+		if n > 0 {
+			u := make([]uint16, n)
+			for j := range u {
+				u[j] = binary.LittleEndian.Uint16(b[j*2:])
+			}
+			r[uint16((id-1)*BlockSize+i)] = string(utf16.Decode(u))
+		}
+		b = b[n*2:]
+	}
+	return r, nil
+}
@@ -0,0 +1,68 @@
+package stringtable
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBlocks(t *testing.T) {
+	tbl := New()
+	for id, s := range map[uint16]string{
+		0:      "zero",
+		15:     "fifteen",
+		16:     "sixteen",
+		100:    "한글",
+		0xffff: "last",
+	} {
+		if err := tbl.SetString(id, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blocks := tbl.Blocks()
+	var ids []int
+	for _, b := range blocks {
+		ids = append(ids, b.ID)
+	}
+	if len(ids) != 4 || ids[0] != 1 || ids[1] != 2 || ids[2] != 7 || ids[3] != 4096 {
+		t.Fatalf("wrong block ids; expected [1 2 7 4096], got %v", ids)
+	}
+	// 16 length prefixes and "zero", "fifteen" in UTF-16
+	if size := blocks[0].Size(); size != 16*2+(4+7)*2 {
+		t.Fatalf("wrong block size; expected %d, got %d", 16*2+(4+7)*2, size)
+	}
+	for _, b := range blocks {
+		data := make([]byte, b.Size())
+		b.Read(data)
+		ss, err := DecodeBlock(b.ID, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for id, s := range ss {
+			if s2, _ := tbl.String(id); s != s2 {
+				t.Fatalf("wrong string #%d; expected %q, got %q", id, s2, s)
+			}
+		}
+	}
+}
+
+func TestSetString_tooLong(t *testing.T) {
+	if err := New().SetString(1, strings.Repeat("a", 0x10000)); err == nil {
+		t.Fatal("expected failure for too long string, got no error")
+	}
+}
+
+func TestDecodeBlock_invalidData(t *testing.T) {
+	for i, tc := range []struct {
+		ID   int
+		Data []byte
+	}{
+		{0, make([]byte, 32)},
+		{4097, make([]byte, 32)},
+		{1, make([]byte, 31)},
+		{1, []byte{0x01, 0x00}},
+	} {
+		if _, err := DecodeBlock(tc.ID, tc.Data); err == nil {
+			t.Fatalf("expected failure for case #%d, got no error", i)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package versioninfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strconv"
+	"unicode/utf16"
+
+	"github.com/pkg/errors"
+)
+
+// block is a generic structure that every version info structure
+// shares: Length, ValueLength, Type, Key, Value and Children.
+type block struct {
+	key      string
+	typ      uint16
+	value    []byte
+	children []*block
+}
+
+// Decode decodes VS_VERSIONINFO resource data in b.
+func Decode(b []byte) (*VersionInfo, error) {
+	root, _, err := decodeBlock(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to decode version info")
+	}
+	if root.key != "VS_VERSION_INFO" {
+		return nil, errors.Errorf("invalid version info key: %q", root.key)
+	}
+
+	vi := &VersionInfo{}
+
+	if len(root.value) > 0 {
+		var ffi rawFixedFileInfo
+		if err := binary.Read(bytes.NewReader(root.value), binary.LittleEndian, &ffi); err != nil {
+			return nil, errors.Wrap(err, "failed to read fixed file info")
+		}
+		if ffi.Signature != 0xFEEF04BD {
+			return nil, errors.Errorf("invalid fixed file info signature: %#08x", ffi.Signature)
+		}
+		vi.fixedFileInfo = fixedFileInfo{
+			fileVersion:    uint64(ffi.FileVersionMS)<<32 | uint64(ffi.FileVersionLS),
+			productVersion: uint64(ffi.ProductVersionMS)<<32 | uint64(ffi.ProductVersionLS),
+			fileFlagsMask:  ffi.FileFlagsMask,
+			fileFlags:      ffi.FileFlags,
+			fileOS:         ffi.FileOS,
+			fileType:       ffi.FileType,
+			fileSubtype:    ffi.FileSubtype,
+			fileDate:       uint64(ffi.FileDateMS)<<32 | uint64(ffi.FileDateLS),
+		}
+	}
+
+	for _, c := range root.children {
+		switch c.key {
+		case "StringFileInfo":
+			for _, st := range c.children {
+				if len(st.key) != 8 {
+					return nil, errors.Errorf("invalid string table key: %q", st.key)
+				}
+				language, err := strconv.ParseUint(st.key[:4], 16, 16)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid string table key: %q", st.key)
+				}
+				codepage, err := strconv.ParseUint(st.key[4:], 16, 16)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid string table key: %q", st.key)
+				}
+				vi.stringTable(uint16(language), uint16(codepage), true)
+				for _, s := range st.children {
+					vi.SetString(uint16(language), uint16(codepage), s.key, decodeString(s.value))
+				}
+			}
+		case "VarFileInfo":
+			for _, v := range c.children {
+				if v.key != "Translation" {
+					continue
+				}
+				for i := 0; i+4 <= len(v.value); i += 4 {
+					vi.AddTranslation(binary.LittleEndian.Uint16(v.value[i:]), binary.LittleEndian.Uint16(v.value[i+2:]))
+				}
+			}
+		}
+	}
+
+	return vi, nil
+}
+
+// decodeBlock decodes a block at the start of b and returns it with its
+// length.
+func decodeBlock(b []byte) (*block, int, error) {
+	if len(b) < 6 {
+		return nil, 0, errors.New("block header is truncated")
+	}
+	length := int(binary.LittleEndian.Uint16(b))
+	valueLength := int(binary.LittleEndian.Uint16(b[2:]))
+	typ := binary.LittleEndian.Uint16(b[4:])
+	if length < 6 || length > len(b) {
+		return nil, 0, errors.Errorf("invalid block length: %d", length)
+	}
+	b = b[:length]
+
+	offset := 6
+	var key []uint16
+	for {
+		if offset+2 > length {
+			return nil, 0, errors.New("block key is not terminated")
+		}
+		c := binary.LittleEndian.Uint16(b[offset:])
+		offset += 2
+		if c == 0 {
+			break
+		}
+		key = append(key, c)
+	}
+	offset += int(paddingLength(uint16(offset)))
+	if offset > length {
+		return nil, 0, errors.Errorf("block %q is truncated", string(utf16.Decode(key)))
+	}
+
+	if typ == 1 {
+		valueLength *= 2 // text value's length is in words
+	}
+	if offset+valueLength > length {
+		if typ != 1 {
+			return nil, 0, errors.Errorf("block %q's value is truncated", string(utf16.Decode(key)))
+		}
+		valueLength = length - offset // tolerate wrong text value length
+	}
+	blk := &block{
+		key:   string(utf16.Decode(key)),
+		typ:   typ,
+		value: b[offset : offset+valueLength],
+	}
+	offset += valueLength
+	offset += int(paddingLength(uint16(offset)))
+
+	for offset < length {
+		c, n, err := decodeBlock(b[offset:])
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "failed to decode %q's child block", blk.key)
+		}
+		blk.children = append(blk.children, c)
+		offset += n
+		offset += int(paddingLength(uint16(offset)))
+	}
+
+	return blk, length, nil
+}
+
+func decodeString(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	for len(u) > 0 && u[len(u)-1] == 0 {
+		u = u[:len(u)-1]
+	}
+	return string(utf16.Decode(u))
+}
@@ -206,6 +206,32 @@ func (vi *VersionInfo) SetString(language, codepage uint16, key, value string) {
 	}
 }
 
+// StringTables returns language and codepage pairs of string tables.
+func (vi *VersionInfo) StringTables() [][2]uint16 {
+	if vi.stringFileInfo == nil {
+		return nil
+	}
+	var r [][2]uint16
+	for _, st := range vi.stringFileInfo.stringTables {
+		r = append(r, [2]uint16{st.language, st.codepage})
+	}
+	return r
+}
+
+// Keys returns keys in string table which is indicated by given
+// language and codepage pair, in the order they were set.
+func (vi *VersionInfo) Keys(language, codepage uint16) []string {
+	st := vi.stringTable(language, codepage, false)
+	if st == nil {
+		return nil
+	}
+	var r []string
+	for _, s := range st.strings {
+		r = append(r, s.key)
+	}
+	return r
+}
+
 func (vi *VersionInfo) stringTable(language, codepage uint16, createIfNotExists bool) *stringTable {
 	if vi.stringFileInfo == nil {
 		if !createIfNotExists {
@@ -249,6 +275,18 @@ func (vi *VersionInfo) AddTranslation(language, codepage uint16) {
 	})
 }
 
+// Translations returns language and codepage pairs of translation info.
+func (vi *VersionInfo) Translations() [][2]uint16 {
+	if vi.varFileInfo == nil {
+		return nil
+	}
+	var r [][2]uint16
+	for _, t := range vi.varFileInfo._var.translations {
+		r = append(r, [2]uint16{t.language, t.codepage})
+	}
+	return r
+}
+
 // TODO: add methods for getting/setting FileFlags, OS, etc.
 
 type fixedFileInfo struct {
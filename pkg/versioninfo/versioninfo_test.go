@@ -50,3 +50,62 @@ func TestWrite(t *testing.T) {
 		t.Fatal("wrong length")
 	}
 }
+
+func TestDecode(t *testing.T) {
+	vi := New()
+	if err := vi.SetFileVersionString("1.2.3.4"); err != nil {
+		t.Fatal(err)
+	}
+	vi.SetString(0x0409, 0x04b0, "CompanyName", "foo")
+	vi.SetString(0x0409, 0x04b0, "ProductName", "bar")
+	vi.SetString(0x0412, 0x04b0, "ProductName", "baz")
+	vi.AddTranslation(0x0409, 0x04b0)
+
+	b := new(bytes.Buffer)
+	if _, err := vi.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+
+	vi2, err := Decode(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := vi2.FileVersionString(); v != "1.2.3.4" {
+		t.Fatalf("wrong file version; expected %q, got %q", "1.2.3.4", v)
+	}
+	if sts := vi2.StringTables(); len(sts) != 2 {
+		t.Fatalf("wrong string tables length; expected 2, got %d", len(sts))
+	}
+	if keys := vi2.Keys(0x0409, 0x04b0); len(keys) != 2 {
+		t.Fatalf("wrong keys length; expected 2, got %d", len(keys))
+	}
+	for _, tc := range []struct {
+		Language uint16
+		Key      string
+		Value    string
+	}{
+		{0x0409, "CompanyName", "foo"},
+		{0x0409, "ProductName", "bar"},
+		{0x0412, "ProductName", "baz"},
+	} {
+		if v, ok := vi2.String(tc.Language, 0x04b0, tc.Key); !ok || v != tc.Value {
+			t.Fatalf("wrong string %q; expected %q, got %q", tc.Key, tc.Value, v)
+		}
+	}
+	if ts := vi2.Translations(); len(ts) != 1 || ts[0] != [2]uint16{0x0409, 0x04b0} {
+		t.Fatalf("wrong translations; got %v", ts)
+	}
+}
+
+func TestDecode_invalidData(t *testing.T) {
+	for _, tc := range [][]byte{
+		{},
+		{0x06, 0x00, 0x00, 0x00, 0x00, 0x00},
+		{0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x41, 0x00},
+		{0x0a, 0x00, 0x00, 0x00, 0x01, 0x00, 0x41, 0x00, 0x00, 0x00}, // key padding exceeds length
+	} {
+		if _, err := Decode(tc); err == nil {
+			t.Fatal("expected failure, got no error")
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package syso
+
+import (
+	"unicode/utf16"
+
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/rsrc"
+	"github.com/hallazzang/syso/pkg/stringtable"
+	"github.com/pkg/errors"
+)
+
+// StringTableResource represents strings in a language, which can be
+// loaded with LoadString. They are packed into string table resources
+// of 16 strings each.
+type StringTableResource struct {
+	Language *string
+	Strings  map[int]string
+}
+
+// Validate returns an error if the resource is invalid.
+func (r *StringTableResource) Validate() error {
+	if _, err := languageID(r.Language); err != nil {
+		return errors.Wrap(err, "failed to parse language identifier")
+	}
+	for id, s := range r.Strings {
+		if id < 0 || id > 0xffff {
+			return errors.Errorf("invalid string id; %d", id)
+		} else if n := len(utf16.Encode([]rune(s))); n > 0xffff {
+			return errors.Errorf("string #%d is too long; %d characters", id, n)
+		}
+	}
+	return nil
+}
+
+// EmbedStringTable embeds strings into c as string table resources.
+func EmbedStringTable(c *coff.File, st *StringTableResource) error {
+	if err := st.Validate(); err != nil {
+		return errors.Wrap(err, "invalid string table")
+	}
+	r, err := getOrCreateRSRCSection(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to get or create .rsrc section")
+	}
+	t := stringtable.New()
+	for id, s := range st.Strings {
+		if err := t.SetString(uint16(id), s); err != nil {
+			return err
+		}
+	}
+	lang, _ := languageID(st.Language)
+	for _, b := range t.Blocks() {
+		if err := r.AddResource(rsrc.StringResource, b.ID, int(lang), b); err != nil {
+			return errors.Wrapf(err, "failed to add string table block #%d", b.ID)
+		}
+	}
+	return nil
+}
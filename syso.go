@@ -5,6 +5,8 @@ import (
 	"io"
 	"os"
 
+	"github.com/hallazzang/syso/pkg/ani"
+	"github.com/hallazzang/syso/pkg/bmp"
 	"github.com/hallazzang/syso/pkg/coff"
 	"github.com/hallazzang/syso/pkg/common"
 	"github.com/hallazzang/syso/pkg/ico"
@@ -14,32 +16,167 @@ import (
 
 // FileResource represents a file resource that can be found at Path.
 type FileResource struct {
-	ID   int
-	Name string
-	Path string
+	ID       int
+	Name     string
+	Language *string
+	Path     string
 }
 
 // Validate returns an error if the resource is invalid.
 func (r *FileResource) Validate() error {
 	if r.Path == "" {
 		return errors.New("no file path given")
-	} else if r.ID == 0 && r.Name == "" {
+	}
+	return validateIdentifier(r.identity())
+}
+
+// identity returns the resource's id, name and language.
+func (r *FileResource) identity() (int, string, *string) {
+	return r.ID, r.Name, r.Language
+}
+
+// validateIdentifier returns an error if a resource's id, name or
+// language is invalid.
+func validateIdentifier(id int, name string, lang *string) error {
+	if id == 0 && name == "" {
 		return errors.New("neither id nor name given")
-	} else if r.ID != 0 && r.Name != "" {
+	} else if id != 0 && name != "" {
 		return errors.New("id and name cannot be set together")
-	} else if r.ID < 0 {
-		return errors.Errorf("invalid id: %d", r.ID)
-	} else if r.Path == "" {
-		return errors.New("path should be set")
+	} else if id < 0 || id > 0xffff {
+		return errors.Errorf("invalid id: %d", id)
+	} else if _, err := languageID(lang); err != nil {
+		return errors.Wrap(err, "failed to parse language identifier")
+	}
+	return nil
+}
+
+// identifier returns a resource's integer id or name.
+func identifier(id int, name string) interface{} {
+	if id != 0 {
+		return id
+	}
+	return name
+}
+
+// ResourceType is a resource type, which is either a predefined
+// integer type such as 10(RT_RCDATA) or a custom type name such as
+// "MYDATA". In JSON, it is written as a number or a string.
+type ResourceType struct {
+	ID   int
+	Name string
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (t *ResourceType) UnmarshalJSON(b []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return errors.Errorf("invalid resource type: %v", v)
+		}
+		*t = ResourceType{ID: int(v)}
+	case string:
+		*t = ResourceType{Name: v}
+	default:
+		return errors.Errorf("resource type must be a number or a string, got %s", b)
 	}
 	return nil
 }
 
+// Validate returns an error if the resource type is invalid.
+func (t *ResourceType) Validate() error {
+	if t.ID == 0 && t.Name == "" {
+		return errors.New("neither type id nor type name given")
+	} else if t.ID != 0 && t.Name != "" {
+		return errors.New("type id and type name cannot be set together")
+	} else if t.ID < 0 || t.ID > 0xffff {
+		return errors.Errorf("invalid type id: %d", t.ID)
+	}
+	return nil
+}
+
+// identifier returns the type's integer id or name.
+func (t *ResourceType) identifier() interface{} {
+	if t.ID != 0 {
+		return t.ID
+	}
+	return t.Name
+}
+
+// RawResource represents a file resource that is embedded as is, with
+// arbitrary resource type.
+type RawResource struct {
+	FileResource
+	Type *ResourceType // default RT_RCDATA
+}
+
+// Validate returns an error if the resource is invalid.
+func (r *RawResource) Validate() error {
+	if err := r.FileResource.Validate(); err != nil {
+		return err
+	}
+	if r.Type != nil {
+		if err := r.Type.Validate(); err != nil {
+			return errors.Wrap(err, "invalid resource type")
+		}
+	}
+	return nil
+}
+
+func (r *RawResource) resourceType() ResourceType {
+	if r.Type == nil {
+		return ResourceType{ID: rsrc.RCDataResource}
+	}
+	return *r.Type
+}
+
+// IDRange is an inclusive range of integer resource ids.
+type IDRange struct {
+	From int
+	To   int
+}
+
+// DefaultImageIDs is the default id range for icon and cursor images.
+var DefaultImageIDs = &IDRange{From: 1000, To: 0xffff}
+
+// Validate returns an error if the range is invalid.
+func (r *IDRange) Validate() error {
+	if r.From < 1 || r.From > 0xffff {
+		return errors.Errorf("invalid range start: %d", r.From)
+	} else if r.To < 1 || r.To > 0xffff {
+		return errors.Errorf("invalid range end: %d", r.To)
+	} else if r.From > r.To {
+		return errors.Errorf("range start(%d) is greater than range end(%d)", r.From, r.To)
+	}
+	return nil
+}
+
+// contains reports whether id is in the range.
+func (r *IDRange) contains(id int) bool {
+	return id >= r.From && id <= r.To
+}
+
 // Config is a syso config data.
 type Config struct {
-	Icons        []*FileResource
-	Manifest     *FileResource
-	VersionInfos []*VersionInfoResource
+	Architectures   []string
+	ImageIDs        *IDRange
+	Icons           []*IconResource
+	Cursors         []*CursorResource
+	AnimatedCursors []*FileResource
+	AnimatedIcons   []*FileResource
+	Bitmaps         []*FileResource
+	Manifest        *ManifestResource
+	VersionInfos    []*VersionInfoResource
+	StringTables    []*StringTableResource
+	MessageTables   []*MessageTableResource
+	Dialogs         []*DialogResource
+	Menus           []*MenuResource
+	Accelerators    []*AcceleratorTableResource
+	Resources       []*RawResource
+	Directories     []*DirectoryResource
 }
 
 // ParseConfig reads JSON-formatted syso config from r and returns Config object.
@@ -48,87 +185,285 @@ func ParseConfig(r io.Reader) (*Config, error) {
 	if err := json.NewDecoder(r).Decode(&c); err != nil {
 		return nil, errors.Wrap(err, "failed to decode JSON")
 	}
-	for i, icon := range c.Icons {
-		if err := icon.Validate(); err != nil {
-			return nil, errors.Wrapf(err, "failed to validate icon #%d", i)
+	for i, arch := range c.Architectures {
+		if _, err := coff.MachineFromGOARCH(arch); err != nil {
+			return nil, errors.Wrapf(err, "failed to validate architecture #%d", i)
 		}
-		for j, icon2 := range c.Icons[:i] {
-			if icon.ID != 0 && icon2.ID != 0 && icon2.ID == icon.ID {
-				return nil, errors.Errorf("icon #%d's id and icon #%d's id are same", i, j)
-			} else if icon.Name != "" && icon2.Name != "" && icon2.Name == icon.Name {
-				return nil, errors.Errorf("icon #%d's name and icon #%d's name are same", i, j)
-			}
+	}
+	if c.ImageIDs != nil {
+		if err := c.ImageIDs.Validate(); err != nil {
+			return nil, errors.Wrap(err, "failed to validate image id range")
+		}
+	}
+	for _, icon := range c.Icons {
+		if icon.ImageIDs == nil {
+			icon.ImageIDs = c.ImageIDs
+		}
+	}
+	for _, cursor := range c.Cursors {
+		if cursor.ImageIDs == nil {
+			cursor.ImageIDs = c.ImageIDs
 		}
 	}
+	if err := validateResources("icon", len(c.Icons), func(i int) identifiedResource { return c.Icons[i] }); err != nil {
+		return nil, err
+	}
+	if err := validateResources("cursor", len(c.Cursors), func(i int) identifiedResource { return c.Cursors[i] }); err != nil {
+		return nil, err
+	}
+	if err := validateResources("animated cursor", len(c.AnimatedCursors), func(i int) identifiedResource { return c.AnimatedCursors[i] }); err != nil {
+		return nil, err
+	}
+	if err := validateResources("animated icon", len(c.AnimatedIcons), func(i int) identifiedResource { return c.AnimatedIcons[i] }); err != nil {
+		return nil, err
+	}
+	if err := validateResources("bitmap", len(c.Bitmaps), func(i int) identifiedResource { return c.Bitmaps[i] }); err != nil {
+		return nil, err
+	}
 	if c.Manifest != nil {
 		if err := c.Manifest.Validate(); err != nil {
 			return nil, errors.Wrap(err, "failed to validate manifest")
 		}
 	}
+	for i, st := range c.StringTables {
+		if err := st.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "failed to validate string table #%d", i)
+		}
+		for j, st2 := range c.StringTables[:i] {
+			lang, _ := languageID(st.Language)
+			lang2, _ := languageID(st2.Language)
+			if lang == lang2 {
+				return nil, errors.Errorf("string table #%d's language and string table #%d's language are same", i, j)
+			}
+		}
+	}
+	if err := validateResources("message table", len(c.MessageTables), func(i int) identifiedResource { return c.MessageTables[i] }); err != nil {
+		return nil, err
+	}
+	if err := validateResources("dialog", len(c.Dialogs), func(i int) identifiedResource { return c.Dialogs[i] }); err != nil {
+		return nil, err
+	}
+	if err := validateResources("menu", len(c.Menus), func(i int) identifiedResource { return c.Menus[i] }); err != nil {
+		return nil, err
+	}
+	if err := validateResources("accelerator table", len(c.Accelerators), func(i int) identifiedResource { return c.Accelerators[i] }); err != nil {
+		return nil, err
+	}
+	for i, res := range c.Resources {
+		if err := res.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "failed to validate resource #%d", i)
+		}
+		for j, res2 := range c.Resources[:i] {
+			lang, _ := languageID(res.Language)
+			lang2, _ := languageID(res2.Language)
+			if res.resourceType() != res2.resourceType() || lang != lang2 {
+				continue
+			}
+			if res.ID != 0 && res2.ID != 0 && res2.ID == res.ID {
+				return nil, errors.Errorf("resource #%d's id and resource #%d's id are same", i, j)
+			} else if res.Name != "" && res2.Name != "" && res2.Name == res.Name {
+				return nil, errors.Errorf("resource #%d's name and resource #%d's name are same", i, j)
+			}
+		}
+	}
+	for i, dir := range c.Directories {
+		if err := dir.Validate(); err != nil {
+			return nil, errors.Wrapf(err, "failed to validate directory #%d", i)
+		}
+	}
 	// TODO: validate version info resource
+	if c.Manifest != nil && c.Manifest.Path == "" && len(c.VersionInfos) > 0 {
+		c.Manifest.inheritIdentity(c.VersionInfos[0])
+	}
 	return &c, nil
 }
 
-// EmbedIcon embeds an icon into c.
-func EmbedIcon(c *coff.File, icon *FileResource) error {
-	if err := icon.Validate(); err != nil {
-		return errors.Wrap(err, "invalid icon")
+// identifiedResource is a resource that has an id or name, and a
+// language.
+type identifiedResource interface {
+	Validate() error
+	identity() (id int, name string, lang *string)
+}
+
+// validateResources validates n resources of a kind, returned by at,
+// and checks that no two of them share the same id or name in the same
+// language.
+func validateResources(kind string, n int, at func(i int) identifiedResource) error {
+	for i := 0; i < n; i++ {
+		r := at(i)
+		if err := r.Validate(); err != nil {
+			return errors.Wrapf(err, "failed to validate %s #%d", kind, i)
+		}
+		id, name, lang := r.identity()
+		for j := 0; j < i; j++ {
+			id2, name2, lang2 := at(j).identity()
+			l, _ := languageID(lang)
+			l2, _ := languageID(lang2)
+			if l != l2 {
+				continue
+			}
+			if id != 0 && id2 == id {
+				return errors.Errorf("%s #%d's id and %s #%d's id are same", kind, i, kind, j)
+			} else if name != "" && name2 == name {
+				return errors.Errorf("%s #%d's name and %s #%d's name are same", kind, i, kind, j)
+			}
+		}
+	}
+	return nil
+}
+
+// CursorResource represents a cursor resource read from a cursor file.
+type CursorResource struct {
+	FileResource
+	ImageIDs *IDRange // id range for cursor images, default DefaultImageIDs
+
+	imageIDs []int
+}
+
+// Validate returns an error if the resource is invalid.
+func (r *CursorResource) Validate() error {
+	if r.ImageIDs != nil {
+		if err := r.ImageIDs.Validate(); err != nil {
+			return errors.Wrap(err, "invalid image id range")
+		}
+	}
+	return r.FileResource.Validate()
+}
+
+// AssignedImageIDs returns ids assigned to the cursor's images by the
+// last EmbedCursorResource call.
+func (r *CursorResource) AssignedImageIDs() []int {
+	return r.imageIDs
+}
+
+// EmbedCursor embeds a cursor file into c. Ids for cursor images are
+// allocated in DefaultImageIDs.
+func EmbedCursor(c *coff.File, cursor *FileResource) error {
+	return EmbedCursorResource(c, &CursorResource{FileResource: *cursor})
+}
+
+// EmbedCursorResource embeds a cursor into c. Ids for cursor images are
+// allocated in cursor.ImageIDs, and can be retrieved by
+// cursor.AssignedImageIDs.
+func EmbedCursorResource(c *coff.File, cursor *CursorResource) error {
+	if err := cursor.Validate(); err != nil {
+		return errors.Wrap(err, "invalid cursor")
 	}
 	r, err := getOrCreateRSRCSection(c)
 	if err != nil {
 		return errors.Wrap(err, "failed to get or create .rsrc section")
 	}
-	f, err := os.Open(icon.Path)
+	f, err := os.Open(cursor.Path)
 	if err != nil {
-		return errors.Wrap(err, "failed to open icon file")
+		return errors.Wrap(err, "failed to open cursor file")
 	}
 	defer f.Close()
-	icons, err := ico.DecodeAll(f)
+	cursors, err := ico.DecodeAllCursors(f)
 	if err != nil {
-		return errors.Wrap(err, "failed to decode icon file")
+		return errors.Wrap(err, "failed to decode cursor file")
 	}
-	for i, img := range icons.Images {
-		img.ID = findPossibleID(r, 1000)
-		if err := r.AddResourceByID(rsrc.IconResource, img.ID, img); err != nil {
-			return errors.Wrapf(err, "failed to add icon image #%d", i)
+	lang, _ := languageID(cursor.Language)
+	var assigned []int
+	for i, img := range cursors.Images {
+		img.ID, err = allocateImageID(r, rsrc.CursorResource, cursor.ImageIDs)
+		if err != nil {
+			return errors.Wrapf(err, "failed to allocate id for cursor image #%d", i)
+		}
+		if err := r.AddResource(rsrc.CursorResource, img.ID, int(lang), img); err != nil {
+			return errors.Wrapf(err, "failed to add cursor image #%d", i)
 		}
+		assigned = append(assigned, img.ID)
 	}
-	if icon.ID != 0 {
-		err = r.AddResourceByID(rsrc.IconGroupResource, icon.ID, icons)
-	} else {
-		err = r.AddResourceByName(rsrc.IconGroupResource, icon.Name, icons)
+	if err := r.AddResource(rsrc.CursorGroupResource, identifier(cursor.ID, cursor.Name), int(lang), cursors); err != nil {
+		return errors.Wrap(err, "failed to add cursor group resource")
 	}
+	cursor.imageIDs = assigned
+	return nil
+}
+
+// EmbedAnimatedCursor embeds an animated cursor into c.
+func EmbedAnimatedCursor(c *coff.File, cursor *FileResource) error {
+	return embedAnimation(c, rsrc.AnimatedCursorResource, cursor)
+}
+
+// EmbedAnimatedIcon embeds an animated cursor file into c as an
+// animated icon.
+func EmbedAnimatedIcon(c *coff.File, icon *FileResource) error {
+	return embedAnimation(c, rsrc.AnimatedIconResource, icon)
+}
+
+func embedAnimation(c *coff.File, typ int, res *FileResource) error {
+	if err := res.Validate(); err != nil {
+		return errors.Wrap(err, "invalid animated cursor")
+	}
+	r, err := getOrCreateRSRCSection(c)
 	if err != nil {
-		return errors.Wrap(err, "failed to add icon group resource")
+		return errors.Wrap(err, "failed to get or create .rsrc section")
+	}
+	f, err := os.Open(res.Path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open animated cursor file")
+	}
+	defer f.Close()
+	cursor, err := ani.Decode(f)
+	if err != nil {
+		return errors.Wrap(err, "failed to decode animated cursor file")
+	}
+	lang, _ := languageID(res.Language)
+	if err := r.AddResource(typ, identifier(res.ID, res.Name), int(lang), cursor); err != nil {
+		return errors.Wrap(err, "failed to add animated cursor resource")
 	}
 	return nil
 }
 
-// EmbedManifest embeds a manifest into c.
-func EmbedManifest(c *coff.File, manifest *FileResource) error {
-	if err := manifest.Validate(); err != nil {
-		return errors.Wrap(err, "invalid manifest")
+// EmbedBitmap embeds a bitmap into c. The bitmap file can be either
+// a BMP file or a PNG image.
+func EmbedBitmap(c *coff.File, bitmap *FileResource) error {
+	if err := bitmap.Validate(); err != nil {
+		return errors.Wrap(err, "invalid bitmap")
 	}
 	r, err := getOrCreateRSRCSection(c)
 	if err != nil {
 		return errors.Wrap(err, "failed to get or create .rsrc section")
 	}
-	f, err := os.Open(manifest.Path)
+	f, err := os.Open(bitmap.Path)
 	if err != nil {
-		return errors.Wrap(err, "failed to open manifest file")
+		return errors.Wrap(err, "failed to open bitmap file")
 	}
 	defer f.Close()
-	b, err := common.NewBlob(f)
+	b, err := bmp.Decode(f)
 	if err != nil {
-		return err
+		return errors.Wrap(err, "failed to decode bitmap file")
+	}
+	lang, _ := languageID(bitmap.Language)
+	if err := r.AddResource(rsrc.BitmapResource, identifier(bitmap.ID, bitmap.Name), int(lang), b); err != nil {
+		return errors.Wrap(err, "failed to add bitmap resource")
+	}
+	return nil
+}
+
+// EmbedResource embeds an arbitrary file resource into c.
+func EmbedResource(c *coff.File, res *RawResource) error {
+	if err := res.Validate(); err != nil {
+		return errors.Wrap(err, "invalid resource")
+	}
+	r, err := getOrCreateRSRCSection(c)
+	if err != nil {
+		return errors.Wrap(err, "failed to get or create .rsrc section")
 	}
-	if manifest.ID != 0 {
-		err = r.AddResourceByID(rsrc.ManifestResource, manifest.ID, b)
-	} else {
-		err = r.AddResourceByName(rsrc.ManifestResource, manifest.Name, b)
+	f, err := os.Open(res.Path)
+	if err != nil {
+		return errors.Wrap(err, "failed to open resource file")
 	}
+	defer f.Close()
+	b, err := common.NewBlob(f)
 	if err != nil {
-		return errors.Wrap(err, "failed to add manifest resource")
+		return err
+	}
+	typ := res.resourceType()
+	lang, _ := languageID(res.Language)
+	if err := r.AddResource(typ.identifier(), identifier(res.ID, res.Name), int(lang), b); err != nil {
+		return errors.Wrap(err, "failed to add resource")
 	}
 	return nil
 }
@@ -152,12 +487,9 @@ func getOrCreateRSRCSection(c *coff.File) (*rsrc.Section, error) {
 	return r, nil
 }
 
-func findPossibleID(r *rsrc.Section, from int) int {
-	// TODO: is 65535 a good limit for resource id?
-	for ; from < 65536; from++ {
-		if !r.ResourceIDExists(from) {
-			break
-		}
+func allocateImageID(r *rsrc.Section, typ int, ids *IDRange) (int, error) {
+	if ids == nil {
+		ids = DefaultImageIDs
 	}
-	return from
+	return r.AllocateResourceID(typ, ids.From, ids.To)
 }
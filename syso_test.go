@@ -1 +1,711 @@
 package syso
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hallazzang/syso/pkg/coff"
+	"github.com/hallazzang/syso/pkg/ico"
+	"github.com/hallazzang/syso/pkg/rsrc"
+	"github.com/pkg/errors"
+)
+
+func TestParseConfig_languages(t *testing.T) {
+	for _, tc := range []struct {
+		Config     string
+		ShouldFail bool
+	}{
+		{`{"Icons": [{"ID": 1, "Path": "a.ico"}, {"ID": 1, "Language": "0412", "Path": "a.ico"}]}`, false},
+		{`{"Icons": [{"ID": 1, "Path": "a.ico"}, {"ID": 1, "Language": "0409", "Path": "a.ico"}]}`, true},
+		{`{"Manifest": {"ID": 1, "Language": "xyz", "Path": "a.manifest"}}`, true},
+		{`{"VersionInfos": [{"ID": 1, "Language": "0412"}]}`, false},
+	} {
+		_, err := ParseConfig(strings.NewReader(tc.Config))
+		if tc.ShouldFail && err == nil {
+			t.Fatalf("expected failure for %s, got no error", tc.Config)
+		} else if !tc.ShouldFail && err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestResourceType_UnmarshalJSON(t *testing.T) {
+	for _, tc := range []struct {
+		JSON       string
+		Type       ResourceType
+		ShouldFail bool
+	}{
+		{JSON: `10`, Type: ResourceType{ID: 10}},
+		{JSON: `"MYDATA"`, Type: ResourceType{Name: "MYDATA"}},
+		{JSON: `1.5`, ShouldFail: true},
+		{JSON: `true`, ShouldFail: true},
+	} {
+		var typ ResourceType
+		err := json.Unmarshal([]byte(tc.JSON), &typ)
+		if tc.ShouldFail {
+			if err == nil {
+				t.Fatalf("expected failure for %s, got no error", tc.JSON)
+			}
+			continue
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		if typ != tc.Type {
+			t.Fatalf("wrong type; expected %+v, got %+v", tc.Type, typ)
+		}
+		if err := typ.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseConfig_resources(t *testing.T) {
+	for _, tc := range []struct {
+		Config     string
+		ShouldFail bool
+	}{
+		{`{"Resources": [{"ID": 1, "Path": "a.txt"}, {"Type": "MYDATA", "ID": 1, "Path": "b.txt"}]}`, false},
+		{`{"Resources": [{"Type": 10, "Name": "A", "Path": "a.txt"}, {"Name": "A", "Language": "0412", "Path": "b.txt"}]}`, false},
+		{`{"Resources": [{"Type": 10, "ID": 1, "Path": "a.txt"}, {"ID": 1, "Path": "b.txt"}]}`, true},
+		{`{"Resources": [{"Type": "", "ID": 1, "Path": "a.txt"}]}`, true},
+		{`{"Resources": [{"ID": 1}]}`, true},
+	} {
+		_, err := ParseConfig(strings.NewReader(tc.Config))
+		if tc.ShouldFail && err == nil {
+			t.Fatalf("expected failure for %s, got no error", tc.Config)
+		} else if !tc.ShouldFail && err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEmbedResource(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	for _, res := range []*RawResource{
+		{FileResource: FileResource{ID: 1, Path: filepath.Join("testdata", "icon.ico")}},
+		{FileResource: FileResource{Name: "GOLANG", Path: filepath.Join("testdata", "golang.ico")}, Type: &ResourceType{Name: "MYDATA"}},
+	} {
+		if err := EmbedResource(c, res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := s.(*rsrc.Section)
+	if !r.ResourceIDExists(1) || !r.ResourceNameExists("GOLANG") {
+		t.Fatal("resource not found")
+	}
+}
+
+func TestEmbedCursor(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	if err := EmbedCursor(c, &FileResource{ID: 1, Path: filepath.Join("testdata", "cursor.cur")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := EmbedCursorResource(c, &CursorResource{FileResource: FileResource{ID: 2, Path: filepath.Join("testdata", "icon.ico")}}); err == nil {
+		t.Fatal("expected failure for icon file, got no error")
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := s.(*rsrc.Section).Root().Entries()
+	if len(types) != 2 {
+		t.Fatalf("wrong type entries length; expected 2, got %d", len(types))
+	}
+	for i, typ := range []int{rsrc.CursorResource, rsrc.CursorGroupResource} {
+		if id, _ := types[i].ID(); id != typ {
+			t.Fatalf("wrong type; expected %d, got %d", typ, id)
+		}
+	}
+}
+
+func TestEmbedAnimatedCursor(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	if err := EmbedAnimatedCursor(c, &FileResource{ID: 1, Path: filepath.Join("testdata", "cursor.ani")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := EmbedAnimatedIcon(c, &FileResource{Name: "ANI", Path: filepath.Join("testdata", "cursor.ani")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := EmbedAnimatedCursor(c, &FileResource{ID: 2, Path: filepath.Join("testdata", "cursor.cur")}); err == nil {
+		t.Fatal("expected failure for static cursor file, got no error")
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := s.(*rsrc.Section)
+	if !r.ResourceIDExists(1) || !r.ResourceNameExists("ANI") {
+		t.Fatal("resource not found")
+	}
+}
+
+func TestEmbedBitmap(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	for _, bitmap := range []*FileResource{
+		{ID: 1, Path: filepath.Join("testdata", "bitmap.bmp")},
+		{Name: "PNG", Path: filepath.Join("testdata", "icon16.png")},
+	} {
+		if err := EmbedBitmap(c, bitmap); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := EmbedBitmap(c, &FileResource{ID: 2, Path: filepath.Join("testdata", "icon.ico")}); err == nil {
+		t.Fatal("expected failure for icon file, got no error")
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := s.(*rsrc.Section)
+	if !r.ResourceIDExists(1) || !r.ResourceNameExists("PNG") {
+		t.Fatal("resource not found")
+	}
+}
+
+func TestEmbedIconResource_images(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	if err := EmbedIconResource(c, &IconResource{
+		FileResource: FileResource{ID: 1},
+		Images: []string{
+			filepath.Join("testdata", "icon16.png"),
+			filepath.Join("testdata", "icon256.png"),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := EmbedIconResource(c, &IconResource{
+		FileResource: FileResource{ID: 2, Path: filepath.Join("testdata", "icon.ico")},
+		Images:       []string{filepath.Join("testdata", "icon16.png")},
+	}); err == nil {
+		t.Fatal("expected failure for both path and images, got no error")
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := s.(*rsrc.Section)
+	for _, id := range []int{1, 1000, 1001} {
+		if !r.ResourceIDExists(id) {
+			t.Fatalf("resource %d not found", id)
+		}
+	}
+}
+
+func TestEmbedIconResource_source(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	if err := EmbedIconResource(c, &IconResource{
+		FileResource: FileResource{ID: 1},
+		Source:       filepath.Join("testdata", "icon256.png"),
+		Sizes:        []int{16, 32},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, icon := range []*IconResource{
+		{FileResource: FileResource{ID: 2}, Source: filepath.Join("testdata", "icon16.png")},
+		{FileResource: FileResource{ID: 3}, Images: []string{filepath.Join("testdata", "icon16.png")}, Sizes: []int{16}},
+		{FileResource: FileResource{ID: 4, Path: filepath.Join("testdata", "icon.ico")}, Sizes: []int{16}},
+	} {
+		if err := EmbedIconResource(c, icon); err == nil {
+			t.Fatalf("expected failure for icon #%d, got no error", icon.ID)
+		}
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := s.(*rsrc.Section)
+	for _, id := range []int{1, 1000, 1001} {
+		if !r.ResourceIDExists(id) {
+			t.Fatalf("resource %d not found", id)
+		}
+	}
+}
+
+func TestEmbedIcon_sharedImages(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	for _, id := range []int{1, 2} {
+		if err := EmbedIcon(c, &FileResource{ID: id, Path: filepath.Join("testdata", "icon.ico")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := s.(*rsrc.Section).Root().Entries()
+	if n := len(types[0].Subdirectory().Entries()); n != 9 {
+		t.Fatalf("wrong icon images count; expected 9, got %d", n)
+	}
+	if n := len(types[1].Subdirectory().Entries()); n != 2 {
+		t.Fatalf("wrong icon groups count; expected 2, got %d", n)
+	}
+}
+
+func TestEmbedIconResource_sharedImageIDs(t *testing.T) {
+	f, err := os.Open(filepath.Join("testdata", "icon.ico"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	g, err := ico.DecodeAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "syso")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "image.bin")
+	if err := ioutil.WriteFile(path, g.Images[0].Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := coff.New(coff.MachineAMD64)
+	// an identical image that is not embedded by EmbedIconResource
+	if err := EmbedResource(c, &RawResource{
+		FileResource: FileResource{ID: 1000, Path: path},
+		Type:         &ResourceType{ID: rsrc.IconResource},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	icon := &IconResource{FileResource: FileResource{ID: 1, Path: filepath.Join("testdata", "icon.ico")}}
+	if err := EmbedIconResource(c, icon); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range icon.AssignedImageIDs() {
+		if id == 1000 {
+			t.Fatalf("image not embedded by EmbedIconResource is shared: %v", icon.AssignedImageIDs())
+		}
+	}
+	// images out of the id range are not shared
+	icon2 := &IconResource{
+		FileResource: FileResource{ID: 2, Path: filepath.Join("testdata", "icon.ico")},
+		ImageIDs:     &IDRange{From: 100, To: 199},
+	}
+	if err := EmbedIconResource(c, icon2); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range icon2.AssignedImageIDs() {
+		if id < 100 || id > 199 {
+			t.Fatalf("wrong image ids; expected ids in 100-199, got %v", icon2.AssignedImageIDs())
+		}
+	}
+}
+
+func TestEmbedIconResource_imageIDs(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	if err := EmbedResource(c, &RawResource{FileResource: FileResource{ID: 100, Path: filepath.Join("testdata", "icon16.png")}}); err != nil {
+		t.Fatal(err)
+	}
+	icon := &IconResource{
+		FileResource: FileResource{ID: 1},
+		ImageIDs:     &IDRange{From: 100, To: 101},
+		Source:       filepath.Join("testdata", "icon256.png"),
+		Sizes:        []int{16, 32},
+	}
+	if err := EmbedIconResource(c, icon); err != nil {
+		t.Fatal(err)
+	}
+	// ids of other resource types don't matter
+	if ids := icon.AssignedImageIDs(); len(ids) != 2 || ids[0] != 100 || ids[1] != 101 {
+		t.Fatalf("wrong image ids; expected [100 101], got %v", ids)
+	}
+	err := EmbedIconResource(c, &IconResource{
+		FileResource: FileResource{ID: 2},
+		ImageIDs:     &IDRange{From: 100, To: 101},
+		Source:       filepath.Join("testdata", "icon256.png"),
+		Sizes:        []int{48},
+	})
+	if errors.Cause(err) != rsrc.ErrIDExhausted {
+		t.Fatalf("expected id exhaustion error, got %v", err)
+	}
+}
+
+func TestParseConfig_imageIDs(t *testing.T) {
+	for _, tc := range []struct {
+		Config     string
+		ShouldFail bool
+	}{
+		{`{"ImageIDs": {"From": 1, "To": 65535}}`, false},
+		{`{"ImageIDs": {"From": 0, "To": 100}}`, true},
+		{`{"ImageIDs": {"From": 100, "To": 65536}}`, true},
+		{`{"ImageIDs": {"From": 100, "To": 99}}`, true},
+		{`{"Icons": [{"ID": 65536, "Path": "a.ico"}]}`, true},
+		{`{"Icons": [{"ID": 1, "Path": "a.ico", "ImageIDs": {"From": 100, "To": 200}}]}`, false},
+		{`{"Cursors": [{"ID": 1, "Path": "a.cur", "ImageIDs": {"From": 0, "To": 200}}]}`, true},
+	} {
+		_, err := ParseConfig(strings.NewReader(tc.Config))
+		if tc.ShouldFail && err == nil {
+			t.Fatalf("expected failure for %s, got no error", tc.Config)
+		} else if !tc.ShouldFail && err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestParseConfig_imageIDsInheritance(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`{
+		"ImageIDs": {"From": 100, "To": 200},
+		"Icons": [{"ID": 1, "Path": "a.ico"}, {"ID": 2, "Path": "b.ico", "ImageIDs": {"From": 300, "To": 400}}],
+		"Cursors": [{"ID": 1, "Path": "a.cur"}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		Name string
+		IDs  *IDRange
+		From int
+	}{
+		{"icon #0", cfg.Icons[0].ImageIDs, 100},
+		{"icon #1", cfg.Icons[1].ImageIDs, 300},
+		{"cursor #0", cfg.Cursors[0].ImageIDs, 100},
+	} {
+		if tc.IDs == nil || tc.IDs.From != tc.From {
+			t.Fatalf("wrong image id range for %s; expected to start from %d, got %+v", tc.Name, tc.From, tc.IDs)
+		}
+	}
+}
+
+func TestEmbedStringTable(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	korean := "0412"
+	for _, st := range []*StringTableResource{
+		{Strings: map[int]string{1: "Hello", 17: "World"}},
+		{Language: &korean, Strings: map[int]string{1: "안녕하세요"}},
+	} {
+		if err := EmbedStringTable(c, st); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := EmbedStringTable(c, &StringTableResource{Strings: map[int]string{2: "foo"}}); err == nil {
+		t.Fatal("expected failure for duplicate block, got no error")
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := s.(*rsrc.Section).Root().Entries()
+	if len(types) != 1 {
+		t.Fatalf("wrong type entries length; expected 1, got %d", len(types))
+	}
+	blocks := types[0].Subdirectory().Entries()
+	if len(blocks) != 2 {
+		t.Fatalf("wrong block entries length; expected 2, got %d", len(blocks))
+	}
+	if langs := blocks[0].Subdirectory().Entries(); len(langs) != 2 {
+		t.Fatalf("wrong language entries length; expected 2, got %d", len(langs))
+	}
+}
+
+func TestParseConfig_stringTables(t *testing.T) {
+	for _, tc := range []struct {
+		Config     string
+		ShouldFail bool
+	}{
+		{`{"StringTables": [{"Strings": {"0": "a", "65535": "b"}}, {"Language": "0412", "Strings": {"1": "c"}}]}`, false},
+		{`{"StringTables": [{"Strings": {"1": "a"}}, {"Language": "0409", "Strings": {"2": "b"}}]}`, true},
+		{`{"StringTables": [{"Strings": {"65536": "a"}}]}`, true},
+		{`{"StringTables": [{"Strings": {"-1": "a"}}]}`, true},
+	} {
+		_, err := ParseConfig(strings.NewReader(tc.Config))
+		if tc.ShouldFail && err == nil {
+			t.Fatalf("expected failure for %s, got no error", tc.Config)
+		} else if !tc.ShouldFail && err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEmbedMessageTable(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	errorSeverity := "Error"
+	for _, mt := range []*MessageTableResource{
+		{FileResource: FileResource{ID: 1, Path: filepath.Join("testdata", "messages.mc")}},
+		{FileResource: FileResource{Name: "MESSAGES"}, Messages: []*Message{
+			{ID: 1, Text: "foo"},
+			{ID: 1, Severity: &errorSeverity, Facility: 0x100, Text: "bar"},
+		}},
+	} {
+		if err := EmbedMessageTable(c, mt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := s.(*rsrc.Section)
+	if !r.ResourceIDExists(1) || !r.ResourceNameExists("MESSAGES") {
+		t.Fatal("resource not found")
+	}
+	langs := r.Root().Entries()[0].Subdirectory().Entries()[1].Subdirectory().Entries()
+	if len(langs) != 2 {
+		t.Fatalf("wrong language entries length; expected 2, got %d", len(langs))
+	}
+}
+
+func TestParseConfig_messageTables(t *testing.T) {
+	for _, tc := range []struct {
+		Config     string
+		ShouldFail bool
+	}{
+		{`{"MessageTables": [{"ID": 1, "Messages": [{"ID": 1, "Text": "a"}, {"ID": 1, "Severity": "Warning", "Text": "b"}]}]}`, false},
+		{`{"MessageTables": [{"ID": 1, "Path": "a.mc"}, {"ID": 1, "Language": "0412", "Messages": [{"ID": 1, "Text": "a"}]}]}`, false},
+		{`{"MessageTables": [{"ID": 1, "Messages": [{"ID": 1, "Text": "a"}, {"ID": 1, "Text": "b"}]}]}`, true},
+		{`{"MessageTables": [{"ID": 1, "Messages": [{"ID": 1, "Severity": "Fatal", "Text": "a"}]}]}`, true},
+		{`{"MessageTables": [{"ID": 1, "Messages": [{"ID": 1, "Facility": 4096, "Text": "a"}]}]}`, true},
+		{`{"MessageTables": [{"ID": 1, "Language": "0412", "Path": "a.mc"}]}`, true},
+		{`{"MessageTables": [{"ID": 1}]}`, true},
+		{`{"MessageTables": [{"ID": 1, "Path": "a.mc"}, {"ID": 1, "Path": "b.mc"}]}`, true},
+	} {
+		_, err := ParseConfig(strings.NewReader(tc.Config))
+		if tc.ShouldFail && err == nil {
+			t.Fatalf("expected failure for %s, got no error", tc.Config)
+		} else if !tc.ShouldFail && err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEmbedDialog(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	var cfg struct {
+		Dialogs []*DialogResource
+	}
+	if err := json.Unmarshal([]byte(`{"Dialogs": [{
+		"ID": 100, "Caption": "About", "Width": 200, "Height": 80,
+		"Style": "WS_POPUP | WS_CAPTION | WS_SYSMENU | DS_MODALFRAME",
+		"Font": {"Typeface": "MS Shell Dlg", "Size": 8},
+		"Controls": [
+			{"Class": "STATIC", "Text": "Hello", "ID": -1, "X": 10, "Y": 10, "Width": 100, "Height": 8},
+			{"Class": "BUTTON", "Text": "OK", "ID": 1, "Style": "WS_VISIBLE | WS_TABSTOP | BS_DEFPUSHBUTTON", "X": 140, "Y": 60, "Width": 50, "Height": 14}
+		]
+	}]}`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := EmbedDialog(c, cfg.Dialogs[0]); err != nil {
+		t.Fatal(err)
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.(*rsrc.Section).ResourceIDExists(100) {
+		t.Fatal("resource not found")
+	}
+}
+
+func TestParseConfig_dialogs(t *testing.T) {
+	for _, tc := range []struct {
+		Config     string
+		ShouldFail bool
+	}{
+		{`{"Dialogs": [{"ID": 1, "Style": 2160590976}, {"Name": "ABOUT", "ExStyle": "WS_EX_TOPMOST"}]}`, false},
+		{`{"Dialogs": [{"ID": 1}, {"ID": 1}]}`, true},
+		{`{"Dialogs": [{"ID": 1, "Style": "WS_FOO"}]}`, true},
+		{`{"Dialogs": [{"ID": 1, "Style": -1}]}`, true},
+		{`{"Dialogs": [{"ID": 1, "Width": 32768}]}`, true},
+		{`{"Dialogs": [{"ID": 1, "Font": {"Size": 8}}]}`, true},
+		{`{"Dialogs": [{"ID": 1, "Controls": [{"Text": "a"}]}]}`, true},
+		{`{"Dialogs": [{"ID": 1, "Controls": [{"Class": "BUTTON", "ID": 65536}]}]}`, true},
+	} {
+		_, err := ParseConfig(strings.NewReader(tc.Config))
+		if tc.ShouldFail && err == nil {
+			t.Fatalf("expected failure for %s, got no error", tc.Config)
+		} else if !tc.ShouldFail && err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEmbedMenu(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	korean := "0412"
+	for _, m := range []*MenuResource{
+		{ID: 1, Items: []*MenuItem{
+			{Text: "Tray", Items: []*MenuItem{
+				{Text: "&Open", ID: 100, Flags: "MFS_DEFAULT"},
+				{Flags: "MFT_SEPARATOR"},
+				{Text: "E&xit", ID: 101},
+			}},
+		}},
+		{ID: 1, Language: &korean, Items: []*MenuItem{
+			{Text: "Tray", Items: []*MenuItem{
+				{Text: "열기(&O)", ID: 100, Flags: "MFS_DEFAULT"},
+			}},
+		}},
+	} {
+		if err := EmbedMenu(c, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := s.(*rsrc.Section)
+	if !r.ResourceIDExists(1) {
+		t.Fatal("resource not found")
+	}
+	if langs := r.Root().Entries()[0].Subdirectory().Entries()[0].Subdirectory().Entries(); len(langs) != 2 {
+		t.Fatalf("wrong language entries length; expected 2, got %d", len(langs))
+	}
+}
+
+func TestParseConfig_menus(t *testing.T) {
+	for _, tc := range []struct {
+		Config     string
+		ShouldFail bool
+	}{
+		{`{"Menus": [{"ID": 1, "Items": [{"Text": "File", "Items": [{"Text": "Exit", "ID": 1}]}]}]}`, false},
+		{`{"Menus": [{"ID": 1, "Items": [{"Text": "a"}]}, {"ID": 1, "Items": [{"Text": "b"}]}]}`, true},
+		{`{"Menus": [{"ID": 1}]}`, true},
+		{`{"Menus": [{"ID": 1, "Items": [{"Text": "a", "Flags": "MF_FOO"}]}]}`, true},
+		{`{"Menus": [{"ID": 1, "Items": [{"Text": "a", "Items": [{"Text": "b", "ID": 65536}]}]}]}`, true},
+	} {
+		_, err := ParseConfig(strings.NewReader(tc.Config))
+		if tc.ShouldFail && err == nil {
+			t.Fatalf("expected failure for %s, got no error", tc.Config)
+		} else if !tc.ShouldFail && err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEmbedAcceleratorTable(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	if err := EmbedAcceleratorTable(c, &AcceleratorTableResource{ID: 1, Entries: []*Accelerator{
+		{Key: "O", Modifiers: []string{"Ctrl"}, ID: 100},
+		{Key: "F5", ID: 101},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.(*rsrc.Section).ResourceIDExists(1) {
+		t.Fatal("resource not found")
+	}
+}
+
+func TestParseConfig_accelerators(t *testing.T) {
+	for _, tc := range []struct {
+		Config     string
+		ShouldFail bool
+	}{
+		{`{"Accelerators": [{"ID": 1, "Entries": [{"Key": "o", "Modifiers": ["Ctrl"], "ID": 1}, {"Key": "o", "ID": 2}]}]}`, false},
+		{`{"Accelerators": [{"ID": 1, "Entries": [{"Key": "o", "Modifiers": ["Ctrl"], "ID": 1}, {"Key": "O", "Modifiers": ["Ctrl", "NoInvert"], "ID": 2}]}]}`, true},
+		{`{"Accelerators": [{"ID": 1, "Entries": [{"Key": "F1", "ID": 1}]}, {"ID": 1, "Entries": [{"Key": "F2", "ID": 1}]}]}`, true},
+		{`{"Accelerators": [{"ID": 1}]}`, true},
+		{`{"Accelerators": [{"ID": 1, "Entries": [{"Key": "F1", "ID": 65536}]}]}`, true},
+		{`{"Accelerators": [{"ID": 1, "Entries": [{"Key": "Foo", "ID": 1}]}]}`, true},
+	} {
+		_, err := ParseConfig(strings.NewReader(tc.Config))
+		if tc.ShouldFail && err == nil {
+			t.Fatalf("expected failure for %s, got no error", tc.Config)
+		} else if !tc.ShouldFail && err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEmbedManifestResource(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`{
+		"Manifest": {"ID": 1, "DPIAwareness": "PerMonitorV2", "LongPathAware": true, "CommonControls": true},
+		"VersionInfos": [{"ID": 1, "Fixed": {"FileVersion": "1.2.3.4"}, "StringTables": [{"Strings": {"InternalName": "foo"}}]}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := cfg.Manifest.Identity; id == nil || id.Name != "foo" || id.Version != "1.2.3.4" {
+		t.Fatalf("wrong identity; expected (foo, 1.2.3.4), got %+v", id)
+	}
+	c := coff.New(coff.MachineAMD64)
+	if err := EmbedManifestResource(c, cfg.Manifest); err != nil {
+		t.Fatal(err)
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	de := s.(*rsrc.Section).Root().Entries()[0].Subdirectory().Entries()[0].Subdirectory().Entries()[0].DataEntry()
+	b, err := de.Data().Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{`name="foo" version="1.2.3.4"`, `level="asInvoker"`, "PerMonitorV2", "longPathAware", "Common-Controls"} {
+		if !strings.Contains(string(b), s) {
+			t.Fatalf("generated manifest doesn't contain %s:\n%s", s, b)
+		}
+	}
+}
+
+func TestParseConfig_manifest(t *testing.T) {
+	for _, tc := range []struct {
+		Config     string
+		ShouldFail bool
+	}{
+		{`{"Manifest": {"ID": 1, "Path": "a.manifest"}}`, false},
+		{`{"Manifest": {"ID": 1}}`, false},
+		{`{"Manifest": {"ID": 1, "ExecutionLevel": "requireAdministrator", "SupportedOS": ["7", "10"], "UTF8": true}}`, false},
+		{`{"Manifest": {"ID": 1, "Path": "a.manifest", "LongPathAware": true}}`, true},
+		{`{"Manifest": {"ID": 1, "ExecutionLevel": "root"}}`, true},
+		{`{"Manifest": {"ID": 1, "DPIAwareness": "yes"}}`, true},
+		{`{"Manifest": {"ID": 1, "SupportedOS": ["xp"]}}`, true},
+		{`{"Manifest": {"ID": 1, "Identity": {"Name": "foo", "Version": "1.0"}}}`, true}, // version must have four parts
+	} {
+		_, err := ParseConfig(strings.NewReader(tc.Config))
+		if tc.ShouldFail && err == nil {
+			t.Fatalf("expected failure for %s, got no error", tc.Config)
+		} else if !tc.ShouldFail && err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestEmbedManifest(t *testing.T) {
+	c := coff.New(coff.MachineAMD64)
+	if err := EmbedManifest(c, &FileResource{ID: 1, Path: filepath.Join("testdata", "app.manifest")}); err != nil {
+		t.Fatal(err)
+	}
+	s, err := c.Section(".rsrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	de := s.(*rsrc.Section).Root().Entries()[0].Subdirectory().Entries()[0].Subdirectory().Entries()[0].DataEntry()
+	b, err := de.Data().Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, []byte("<?xml")) {
+		t.Fatalf("BOM is not stripped from manifest: % x", b[:8])
+	}
+
+	dir, err := ioutil.TempDir("", "syso")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bad.manifest")
+	if err := ioutil.WriteFile(path, bytes.Replace(b, []byte("asInvoker"), []byte("admin"), 1), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := EmbedManifest(coff.New(coff.MachineAMD64), &FileResource{ID: 1, Path: path}); err == nil {
+		t.Fatal("expected failure for invalid manifest file, got no error")
+	}
+	if err := EmbedManifest(coff.New(coff.MachineAMD64), &FileResource{ID: 1}); err == nil {
+		t.Fatal("expected failure for no manifest file, got no error")
+	}
+}
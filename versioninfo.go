@@ -16,6 +16,7 @@ import (
 type VersionInfoResource struct {
 	ID           *int
 	Name         *string
+	Language     *string
 	Fixed        *VersionInfoFixed
 	StringTables []*VersionInfoStringTable
 	Translations []*VersionInfoTranslation
@@ -27,10 +28,12 @@ func (r *VersionInfoResource) Validate() error {
 		return errors.New("resource id or name must be given")
 	} else if r.ID != nil && r.Name != nil {
 		return errors.New("resource id and name cannot be given at same time")
-	} else if r.ID != nil && *r.ID < 1 {
+	} else if r.ID != nil && (*r.ID < 1 || *r.ID > 0xffff) {
 		return errors.Errorf("invalid resource id; %d", *r.ID)
 	} else if r.Name != nil && *r.Name == "" {
 		return errors.New("resource name cannot be empty")
+	} else if _, err := languageID(r.Language); err != nil {
+		return errors.Wrap(err, "failed to parse language identifier")
 	}
 
 	if r.Fixed != nil {
@@ -89,7 +92,7 @@ type VersionInfoStringTable struct {
 
 // Validate returns data validation result.
 func (st *VersionInfoStringTable) Validate() error {
-	if _, err := st.languageID(); err != nil {
+	if _, err := languageID(st.Language); err != nil {
 		return errors.Wrap(err, "failed to parse language identifier")
 	}
 	if _, err := st.charsetID(); err != nil {
@@ -102,10 +105,6 @@ func (st *VersionInfoStringTable) Validate() error {
 	return nil
 }
 
-func (st *VersionInfoStringTable) languageID() (uint16, error) {
-	return parseUint16ID(st.Language, 0x0409) // default English
-}
-
 func (st *VersionInfoStringTable) charsetID() (uint16, error) {
 	return parseUint16ID(st.Charset, 0x04b0) // default unicode
 }
@@ -199,7 +198,7 @@ func EmbedVersionInfo(c *coff.File, v *VersionInfoResource) error {
 	}
 
 	for _, st := range v.StringTables {
-		lang, _ := st.languageID()
+		lang, _ := languageID(st.Language)
 		charset, _ := st.charsetID()
 		for _, kv := range st.Strings.fields() {
 			vi.SetString(lang, charset, kv[0], kv[1])
@@ -223,10 +222,11 @@ func EmbedVersionInfo(c *coff.File, v *VersionInfoResource) error {
 		return errors.Wrap(err, "failed to create version info blob")
 	}
 
+	lang, _ := languageID(v.Language)
 	if v.ID != nil {
-		err = r.AddResourceByID(rsrc.VersionInfoResource, *v.ID, b)
+		err = r.AddResource(rsrc.VersionInfoResource, *v.ID, int(lang), b)
 	} else {
-		err = r.AddResourceByName(rsrc.VersionInfoResource, *v.Name, b)
+		err = r.AddResource(rsrc.VersionInfoResource, *v.Name, int(lang), b)
 	}
 	if err != nil {
 		return errors.Wrap(err, "failed to add version info resource")
@@ -235,6 +235,12 @@ func EmbedVersionInfo(c *coff.File, v *VersionInfoResource) error {
 	return nil
 }
 
+// languageID parses a resource language in hex, which defaults to
+// English(0x0409).
+func languageID(s *string) (uint16, error) {
+	return parseUint16ID(s, 0x0409)
+}
+
 func parseUint16ID(s *string, defaultID uint16) (uint16, error) {
 	if s == nil {
 		return defaultID, nil